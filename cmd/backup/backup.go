@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/archive"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	repo    string
+	filters string
+	source  string
+	strip   []string
+}
+
+var repo *archive.Archive
+
+func NewBackupCommand() *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Backup configs to git repository",
+		Long: `Backup device configs to local git repository, one canonical file <ip>.xml per device.
+
+Nothing is stripped by default, volatile elements and attributes can be removed with --strip.
+Stripped names are recorded in commit message and restore refuses such backups, as restoring would
+delete stripped elements from device.
+Every run creates commit listing changed devices, nothing is committed if no config changed.
+
+# backup running configs of all devices
+netconf backup --inventory hosts.ini --repo backups
+
+# backup without volatile timestamps, stripped backups cannot be restored
+netconf backup --inventory hosts.ini --repo backups --strip last-changed,timestamp
+
+# inspect history of device
+netconf backup log 192.168.1.1 --repo backups
+netconf backup show 192.168.1.1@HEAD~1 --repo backups
+netconf backup diff 192.168.1.1 HEAD~2 --repo backups`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			if opts.filters != "" {
+				opts.filters, err = utils.ReadFiltersFromUser(opts.filters)
+				if err != nil {
+					log.Fatalf("Failed to read filters, error: %v", err)
+				}
+			}
+
+			repo, err = archive.Open(opts.repo)
+			if err != nil {
+				log.Fatalf("Failed to open backup repository, error: %v", err)
+			}
+
+			runErr := parallel.RunParallel(cfg, runBackup)
+			commit(cfg)
+			if runErr != nil {
+				log.Fatalf("Failed to execute backup")
+			}
+		},
+	}
+	persistentFlags := backupCmd.PersistentFlags()
+	persistentFlags.StringVar(&opts.repo, "repo", "", "git repository directory for backups")
+	_ = backupCmd.MarkPersistentFlagRequired("repo")
+
+	flags := backupCmd.Flags()
+	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, file containing filters")
+	flags.StringVarP(&opts.source, "source", "s", "running", "running|candidate|startup")
+	flags.StringSliceVar(&opts.strip, "strip", nil, "volatile element and attribute names removed from backups")
+
+	backupCmd.AddCommand(
+		newLogCommand(),
+		newShowCommand(),
+		newDiffCommand(),
+	)
+	return backupCmd
+}
+
+func runBackup(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	reply, err := session.GetConfig(ctx,
+		netconf.Datastore(opts.source),
+		netconf.WithSubtreeFilter(string(filter)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get %s config, ip: %s, error: %v", opts.source, device.IP, err)
+	}
+
+	nodes, err := xmltree.ParseReply(reply.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse %s config, error: %v", opts.source, err)
+	}
+	canonical := utils.FormatXML(xmltree.Marshal(xmltree.Strip(nodes, opts.strip))) + "\n"
+
+	changed, err := repo.Write(device.IP, []byte(canonical))
+	if err != nil {
+		return err
+	}
+	if changed {
+		device.Result.Status = parallel.StatusChanged
+		device.Log.Infof("Config changed, saved to %s", archive.File(device.IP))
+	} else {
+		device.Result.Status = parallel.StatusUnchanged
+	}
+	device.Log.Infof("Executed backup, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
+
+func commit(cfg *config.Config) {
+	var changed, failed []string
+	for _, device := range cfg.Devices {
+		switch device.Result.Status {
+		case parallel.StatusChanged:
+			changed = append(changed, device.IP)
+		case parallel.StatusFailed:
+			failed = append(failed, device.IP)
+		}
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("Backup %s, %d changed devices\n", time.Now().Format(time.DateTime), len(changed)))
+	if len(changed) > 0 {
+		message.WriteString("\nChanged devices:\n")
+		for _, ip := range changed {
+			message.WriteString(ip + "\n")
+		}
+	}
+	if len(failed) > 0 {
+		message.WriteString("\nFailed devices:\n")
+		for _, ip := range failed {
+			message.WriteString(ip + "\n")
+		}
+	}
+	if len(opts.strip) > 0 {
+		message.WriteString("\n" + archive.StripTrailer(opts.strip) + "\n")
+	}
+
+	committed, err := repo.Commit(message.String())
+	if err != nil {
+		log.Errorf("Failed to commit backups, error: %v", err)
+		return
+	}
+	if committed {
+		log.Infof("Committed backups of %d changed devices to %s", len(changed), repo.Dir)
+	} else {
+		log.Info("No config changes, nothing to commit")
+	}
+}
+
+func newLogCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "log DEVICE",
+		Short: "Show backup history of device",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, err := archive.Open(opts.repo)
+			if err != nil {
+				log.Fatalf("Failed to open backup repository, error: %v", err)
+			}
+			history, err := a.Log(args[0])
+			if err != nil {
+				log.Fatalf("Failed to read backup history, error: %v", err)
+			}
+			fmt.Print(history)
+		},
+	}
+}
+
+func newShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show DEVICE[@REV]",
+		Short: "Show backup of device at revision, default HEAD",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, err := archive.Open(opts.repo)
+			if err != nil {
+				log.Fatalf("Failed to open backup repository, error: %v", err)
+			}
+			device, rev := archive.ParseRef(args[0])
+			backup, err := a.Show(device, rev)
+			if err != nil {
+				log.Fatalf("Failed to read backup, error: %v", err)
+			}
+			fmt.Print(string(backup))
+		},
+	}
+}
+
+func newDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff DEVICE [FROM [TO]]",
+		Short: "Show changes of device backup between revisions, default HEAD~1 and HEAD",
+		Args:  cobra.RangeArgs(1, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, err := archive.Open(opts.repo)
+			if err != nil {
+				log.Fatalf("Failed to open backup repository, error: %v", err)
+			}
+			from, to := "HEAD~1", "HEAD"
+			if len(args) > 1 {
+				from = args[1]
+			}
+			if len(args) > 2 {
+				to = args[2]
+			}
+			diff, err := a.Diff(args[0], from, to)
+			if err != nil {
+				log.Fatalf("Failed to diff backups, error: %v", err)
+			}
+			fmt.Print(diff)
+		},
+	}
+}
@@ -0,0 +1,187 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/check"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	xpath          string
+	ns             []string
+	filters        string
+	paths          []string
+	source         string
+	label          string
+	warning        string
+	critical       string
+	expect         string
+	warningString  string
+	criticalString string
+	timeout        time.Duration
+}
+
+var (
+	xpath      *xmltree.XPath
+	thresholds check.Thresholds
+	// executed is set when session is established, errors before it are unknown and after it critical
+	executed bool
+	value    string
+
+	errNoValue = errors.New("xpath selected no nodes")
+)
+
+func NewCheckCommand() *cobra.Command {
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Run nagios check plugin",
+		Long: `Run check against one host as nagios or icinga plugin. Xpath is evaluated locally against reply of get,
+or get-config with --source, and value is compared to thresholds. Single plugin output line with perfdata is printed
+to stdout and exit code is 0 (OK), 1 (WARNING), 2 (CRITICAL) or 3 (UNKNOWN).
+
+Thresholds use nagios ranges, e.g. 10 alerts outside 0..10, 10: below 10, ~:10 above 10 and @10:20 inside 10..20.
+Connection errors and xpath selecting no nodes are UNKNOWN, rpc errors are CRITICAL.
+
+# cpu usage with thresholds
+netconf check --host 192.168.1.1 --filter cpu.xml --xpath "//cpu/usage" --warning 80 --critical 90 --label cpu
+
+# interface must be up
+netconf check --host 192.168.1.1 --path /if:interfaces-state/interface[name=eth0] \
+  --xpath "//interface[name='eth0']/oper-status" --expect up --ns if=urn:ietf:params:xml:ns:yang:ietf-interfaces`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := setup(); err != nil {
+				exit(check.Unknown, err.Error())
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+			defer cancel()
+			cfg, err := config.ParseConfig(ctx)
+			if err != nil {
+				exit(check.Unknown, fmt.Sprintf("failed to init config, %v", err))
+			}
+			if len(cfg.Devices) != 1 {
+				exit(check.Unknown, fmt.Sprintf("check requires exactly one host, got %d", len(cfg.Devices)))
+			}
+
+			if err := parallel.RunParallel(cfg, runCheck); err != nil {
+				if executed && !errors.Is(err, errNoValue) {
+					exit(check.Critical, err.Error())
+				}
+				exit(check.Unknown, err.Error())
+			}
+
+			status := thresholds.Evaluate(value)
+			message := ""
+			if status == check.Unknown {
+				message = fmt.Sprintf("%s value %q is not a number", opts.label, value)
+			}
+			fmt.Println(check.Output(status, opts.label, value, message, thresholds))
+			os.Exit(status)
+		},
+	}
+	flags := checkCmd.Flags()
+	flags.StringVar(&opts.xpath, "xpath", "", "xpath evaluated against reply")
+	flags.StringSliceVar(&opts.ns, "ns", nil, "namespace prefix mapping for xpath and paths, prefix=uri")
+	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, stdin or file containing filters")
+	flags.StringArrayVar(&opts.paths, "path", nil, "path to build subtree filter from, e.g. /prefix:state/port[port-id=1/1/1], repeatable")
+	flags.StringVarP(&opts.source, "source", "s", "", "evaluate get-config of running|candidate|startup instead of get")
+	flags.StringVar(&opts.label, "label", "value", "label of value in output and perfdata")
+	flags.StringVarP(&opts.warning, "warning", "w", "", "warning threshold range")
+	flags.StringVarP(&opts.critical, "critical", "c", "", "critical threshold range")
+	flags.StringVar(&opts.expect, "expect", "", "expected string value, other values are critical")
+	flags.StringVar(&opts.warningString, "warning-string", "", "string value, which is warning")
+	flags.StringVar(&opts.criticalString, "critical-string", "", "string value, which is critical")
+	flags.DurationVarP(&opts.timeout, "timeout", "t", 30*time.Second, "timeout of check")
+	_ = checkCmd.MarkFlagRequired("xpath")
+	checkCmd.MarkFlagsMutuallyExclusive("filter", "path")
+
+	return checkCmd
+}
+
+func setup() error {
+	namespaces, err := config.Namespaces(opts.ns)
+	if err != nil {
+		return fmt.Errorf("failed to parse namespaces, %v", err)
+	}
+	if xpath, err = xmltree.CompileXPath(opts.xpath, namespaces); err != nil {
+		return err
+	}
+	if opts.filters != "" {
+		if opts.filters, err = utils.ReadFiltersFromUser(opts.filters); err != nil {
+			return fmt.Errorf("failed to read filters, %v", err)
+		}
+	}
+	if len(opts.paths) > 0 {
+		if opts.filters, err = xmltree.PathFilter(opts.paths, namespaces); err != nil {
+			return fmt.Errorf("failed to build filter from paths, %v", err)
+		}
+	}
+
+	if thresholds.Warning, err = check.ParseRange(opts.warning); err != nil {
+		return err
+	}
+	if thresholds.Critical, err = check.ParseRange(opts.critical); err != nil {
+		return err
+	}
+	if opts.expect != "" {
+		thresholds.Expect = &opts.expect
+	}
+	if opts.warningString != "" {
+		thresholds.WarningString = &opts.warningString
+	}
+	if opts.criticalString != "" {
+		thresholds.CriticalString = &opts.criticalString
+	}
+	return nil
+}
+
+func runCheck(device *config.Device, session *netconf.Session) error {
+	executed = true
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
+	var reply *netconf.Reply
+	if opts.source != "" {
+		reply, err = session.GetConfig(device.Ctx, netconf.Datastore(opts.source), netconf.WithSubtreeFilter(string(filter)))
+	} else {
+		reply, err = session.Get(device.Ctx, netconf.WithSubtreeFilter(string(filter)))
+	}
+	if err != nil {
+		return err
+	}
+	nodes, err := xmltree.ParseReply(reply.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse reply, %v", err)
+	}
+
+	result := xpath.Evaluate(nodes)
+	if selected, ok := result.([]*xmltree.Node); ok && len(selected) == 0 {
+		return errNoValue
+	}
+	if number, ok := result.(float64); ok {
+		value = strconv.FormatFloat(number, 'f', -1, 64)
+	} else {
+		value = xmltree.XPathString(result)
+	}
+	return nil
+}
+
+func exit(status int, message string) {
+	fmt.Println(check.Output(status, opts.label, "", message, thresholds))
+	os.Exit(status)
+}
@@ -0,0 +1,218 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/archive"
+	"github.com/networkguild/netconf-cli/pkg/compliance"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+const (
+	StatusCompliant    = "compliant"
+	StatusNonCompliant = "non-compliant"
+
+	// ExitNonCompliant is exit code, when any rule fails on any device
+	ExitNonCompliant = 2
+)
+
+var opts struct {
+	rules   string
+	filters string
+	source  string
+	repo    string
+	rev     string
+	json    string
+	junit   string
+}
+
+var (
+	rules *compliance.Rules
+	repo  *archive.Archive
+
+	// results holds rule results per device ip
+	results sync.Map
+)
+
+func NewComplianceCommand() *cobra.Command {
+	complianceCmd := &cobra.Command{
+		Use:   "compliance",
+		Short: "Check configs against compliance rules",
+		Long: `Evaluate compliance rules against device configs, fetched with get-config or read from backup repository.
+
+Rules are written in yaml, each rule has xpath expression and optional checks:
+  count, min, max   number of selected nodes
+  equals            value of every selected node, or value of expression
+  match             regex matching value of every selected node
+Without checks, rule passes when xpath selects any node or evaluates to true.
+
+namespaces:
+  if: urn:ietf:params:xml:ns:yang:ietf-interfaces
+rules:
+  - name: every interface has description
+    xpath: //if:interface[not(if:description)]
+    count: 0
+  - name: ssh v1 disabled
+    xpath: /system/ssh/version
+    equals: "2"
+
+Results are printed as table, exit code is 2 when any rule fails and 1 when any device failed.
+
+# check running configs of all devices
+netconf compliance --inventory hosts.ini --rules rules.yaml --junit compliance.xml
+
+# check latest backups without connecting to devices
+netconf compliance --inventory hosts.ini --rules rules.yaml --repo backups --json compliance.json`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			rules, err = compliance.Load(opts.rules)
+			if err != nil {
+				log.Fatalf("Failed to load rules, error: %v", err)
+			}
+
+			var runErr error
+			if opts.repo != "" {
+				repo, err = archive.Open(opts.repo)
+				if err != nil {
+					log.Fatalf("Failed to open backup repository, error: %v", err)
+				}
+				runErr = parallel.RunLocal(cfg, runBackupCompliance)
+			} else {
+				if opts.filters != "" {
+					opts.filters, err = utils.ReadFiltersFromUser(opts.filters)
+					if err != nil {
+						log.Fatalf("Failed to read filters, error: %v", err)
+					}
+				}
+				runErr = parallel.RunParallel(cfg, runCompliance)
+			}
+
+			devices, nonCompliant := collect(cfg)
+			if err := compliance.PrintTable(os.Stdout, devices); err != nil {
+				log.Errorf("Failed to print results, error: %v", err)
+			}
+			if opts.json != "" {
+				if err := compliance.WriteJSON(opts.json, devices); err != nil {
+					log.Errorf("Failed to write json results, error: %v", err)
+				}
+			}
+			if opts.junit != "" {
+				if err := compliance.WriteJUnit(opts.junit, devices); err != nil {
+					log.Errorf("Failed to write junit results, error: %v", err)
+				}
+			}
+
+			if runErr != nil {
+				log.Fatalf("Failed to execute compliance")
+			}
+			if nonCompliant > 0 {
+				log.Warnf("Compliance rules failed on %d devices", nonCompliant)
+				os.Exit(ExitNonCompliant)
+			}
+		},
+	}
+	flags := complianceCmd.Flags()
+	flags.StringVar(&opts.rules, "rules", "", "yaml file containing compliance rules")
+	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, file containing filters")
+	flags.StringVarP(&opts.source, "source", "s", "running", "running|candidate|startup")
+	flags.StringVar(&opts.repo, "repo", "", "evaluate backups from git repository instead of live configs")
+	flags.StringVar(&opts.rev, "rev", "HEAD", "backup revision, used with --repo")
+	flags.StringVar(&opts.json, "json", "", "write json results to file")
+	flags.StringVar(&opts.junit, "junit", "", "write junit xml results to file")
+	_ = complianceCmd.MarkFlagRequired("rules")
+	complianceCmd.MarkFlagsMutuallyExclusive("repo", "filter")
+
+	return complianceCmd
+}
+
+func runCompliance(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	reply, err := session.GetConfig(ctx,
+		netconf.Datastore(opts.source),
+		netconf.WithSubtreeFilter(string(filter)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get %s config, ip: %s, error: %v", opts.source, device.IP, err)
+	}
+	if err := evaluate(device, []byte(reply.String())); err != nil {
+		return err
+	}
+	device.Log.Infof("Executed compliance, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
+
+func runBackupCompliance(device *config.Device) error {
+	data, err := repo.Show(device.IP, opts.rev)
+	if err != nil {
+		return err
+	}
+	return evaluate(device, data)
+}
+
+func evaluate(device *config.Device, data []byte) error {
+	nodes, err := xmltree.ParseReply(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse config, error: %v", err)
+	}
+
+	deviceResults := rules.Evaluate(nodes)
+	results.Store(device.IP, deviceResults)
+
+	var failed int
+	for _, result := range deviceResults {
+		if !result.Passed {
+			failed++
+			device.Log.Warnf("Rule %q failed: %s", result.Rule, result.Message)
+		}
+	}
+	device.Result.SetDetail("rules", fmt.Sprintf("%d passed, %d failed", len(deviceResults)-failed, failed))
+	if failed > 0 {
+		device.Result.Status = StatusNonCompliant
+	} else {
+		device.Result.Status = StatusCompliant
+	}
+	return nil
+}
+
+// collect returns results of all devices in inventory order and count of non-compliant devices.
+func collect(cfg *config.Config) ([]compliance.Device, int) {
+	var (
+		devices      []compliance.Device
+		nonCompliant int
+	)
+	for _, d := range cfg.Devices {
+		device := compliance.Device{IP: d.IP, Error: d.Result.Error}
+		if r, found := results.Load(d.IP); found {
+			device.Results = r.([]compliance.Result)
+		}
+		if device.Failed() > 0 {
+			nonCompliant++
+		}
+		devices = append(devices, device)
+	}
+	return devices, nonCompliant
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf"
 	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/output"
 	"github.com/networkguild/netconf-cli/pkg/parallel"
 	"github.com/spf13/cobra"
 )
@@ -81,6 +82,7 @@ func runCopyConfig(device *config.Device, session *netconf.Session) error {
 	if err := session.CopyConfig(ctx, source, target); err != nil {
 		return err
 	}
+	output.CollectOK(device, "copy-config")
 
 	device.Log.Infof("Executed copy-config request, took %.3f seconds", time.Since(start).Seconds())
 	return nil
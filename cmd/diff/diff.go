@@ -0,0 +1,263 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/archive"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+const (
+	formatTree    = "tree"
+	formatUnified = "unified"
+
+	filePrefix   = "file:"
+	backupPrefix = "backup:"
+)
+
+var opts struct {
+	format  string
+	repo    string
+	filters string
+}
+
+var (
+	repo   *archive.Archive
+	from   source
+	to     source
+	filter []byte
+)
+
+// source is one side of diff, device datastore, local file or backup revision.
+type source struct {
+	datastore string
+	file      string
+	backup    string
+}
+
+func NewDiffCommand() *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff SOURCE TARGET",
+		Short: "Compare configs semantically",
+		Long: `Compare two configs semantically, sources can be device datastores, local files or backup revisions:
+
+running|candidate|startup   datastore of each device
+file:PATH                   local file, rendered as template per device
+backup:[DEVICE]@REV         backup revision from --repo, DEVICE defaults to each device
+
+Comparison is namespace aware, list entries are matched by keys regardless of their order
+and whitespace of values is normalized. Tree format lists added (+), removed (-) and changed (~) paths,
+unified format shows unified diff of canonically ordered configs.
+
+# compare running and candidate datastores
+netconf diff running candidate --host 192.168.1.1
+
+# compare running config to previous backup
+netconf diff backup:@HEAD~1 running --inventory hosts.ini --repo backups
+
+# compare two files, no devices needed
+netconf diff file:old.xml file:new.xml --format unified`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			from, to = parseSource(args[0]), parseSource(args[1])
+			if opts.format != formatTree && opts.format != formatUnified {
+				log.Fatalf("Invalid format %s, must be %s or %s", opts.format, formatTree, formatUnified)
+			}
+
+			if from.backup != "" || to.backup != "" {
+				if opts.repo == "" {
+					log.Fatal("Backup repository --repo must be specified with backup sources")
+				}
+				var err error
+				repo, err = archive.Open(opts.repo)
+				if err != nil {
+					log.Fatalf("Failed to open backup repository, error: %v", err)
+				}
+			}
+
+			if !from.perDevice() && !to.perDevice() {
+				different, err := diffLocal()
+				if err != nil {
+					log.Fatalf("Failed to diff configs, error: %v", err)
+				}
+				if different {
+					os.Exit(1)
+				}
+				return
+			}
+
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+			if opts.filters != "" {
+				f, err := utils.ReadFiltersFromUser(opts.filters)
+				if err != nil {
+					log.Fatalf("Failed to read filters, error: %v", err)
+				}
+				filter = []byte(f)
+			}
+
+			if err := parallel.RunParallel(cfg, runDiff); err != nil {
+				log.Fatalf("Failed to execute diff")
+			}
+		},
+	}
+	flags := diffCmd.Flags()
+	flags.StringVar(&opts.format, "format", formatTree, "output format, tree|unified")
+	flags.StringVar(&opts.repo, "repo", "", "git repository directory for backups")
+	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option for datastore sources, file containing filters")
+
+	return diffCmd
+}
+
+func parseSource(spec string) source {
+	switch {
+	case spec == string(netconf.Running), spec == string(netconf.Candidate), spec == string(netconf.Startup):
+		return source{datastore: spec}
+	case strings.HasPrefix(spec, backupPrefix):
+		return source{backup: strings.TrimPrefix(spec, backupPrefix)}
+	default:
+		return source{file: strings.TrimPrefix(spec, filePrefix)}
+	}
+}
+
+// perDevice reports whether source needs device, datastores and backups without device name.
+func (s source) perDevice() bool {
+	if s.backup != "" {
+		ip, _ := archive.ParseRef(s.backup)
+		return ip == ""
+	}
+	return s.datastore != ""
+}
+
+func (s source) String() string {
+	switch {
+	case s.datastore != "":
+		return s.datastore
+	case s.backup != "":
+		return backupPrefix + s.backup
+	default:
+		return s.file
+	}
+}
+
+// load reads config of source, device and session are nil, when diff is run locally.
+func (s source) load(ctx context.Context, device *config.Device, session *netconf.Session) ([]*xmltree.Node, error) {
+	var data []byte
+	switch {
+	case s.datastore != "":
+		f, err := render.Render(device, filter)
+		if err != nil {
+			return nil, err
+		}
+		reply, err := session.GetConfig(ctx, netconf.Datastore(s.datastore), netconf.WithSubtreeFilter(string(f)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s config, ip: %s, error: %v", s.datastore, device.IP, err)
+		}
+		data = []byte(reply.String())
+	case s.backup != "":
+		ip, rev := archive.ParseRef(s.backup)
+		if ip == "" {
+			ip = device.IP
+		}
+		b, err := repo.Show(ip, rev)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	default:
+		b, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s, error: %v", s.file, err)
+		}
+		if device != nil {
+			if b, err = render.Render(device, b); err != nil {
+				return nil, err
+			}
+		}
+		data = b
+	}
+
+	nodes, err := xmltree.ParseReply(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s config, error: %v", s, err)
+	}
+	return xmltree.Unwrap(nodes, "config"), nil
+}
+
+func diffLocal() (bool, error) {
+	a, err := from.load(context.Background(), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	b, err := to.load(context.Background(), nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	changes := xmltree.Diff(a, b)
+	if len(changes) > 0 {
+		fmt.Print(format(a, b, changes))
+		return true, nil
+	}
+	log.Info("No differences found")
+	return false, nil
+}
+
+func runDiff(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	a, err := from.load(ctx, device, session)
+	if err != nil {
+		return err
+	}
+	b, err := to.load(ctx, device, session)
+	if err != nil {
+		return err
+	}
+
+	changes := xmltree.Diff(a, b)
+	if len(changes) > 0 {
+		device.Result.Status = parallel.StatusChanged
+		device.Result.Changes = changes
+		device.Log.Infof("Differences between %s and %s:\n%s", from, to, format(a, b, changes))
+	} else {
+		device.Result.Status = parallel.StatusUnchanged
+		device.Log.Infof("No differences between %s and %s", from, to)
+	}
+	device.Log.Infof("Executed diff, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
+
+// format returns changes of a and b in requested format.
+func format(a, b []*xmltree.Node, changes []xmltree.Change) string {
+	if opts.format == formatUnified {
+		xmltree.Canonicalize(a)
+		xmltree.Canonicalize(b)
+		return utils.UnifiedDiff(from.String(), to.String(),
+			utils.FormatXML(xmltree.Marshal(a)),
+			utils.FormatXML(xmltree.Marshal(b)),
+		)
+	}
+
+	var out strings.Builder
+	for _, change := range changes {
+		out.WriteString(change.String())
+		out.WriteString("\n")
+	}
+	return out.String()
+}
@@ -8,7 +8,11 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf"
 	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/lock"
+	"github.com/networkguild/netconf-cli/pkg/output"
 	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/snapshot"
 	"github.com/networkguild/netconf-cli/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -16,9 +20,15 @@ import (
 var opts struct {
 	useLock bool
 	file    string
+
+	snapshotDir string
+	postCheck   string
+	renderOnly  bool
+	output      string
+	lock        lock.Options
 }
 
-var files [][]byte
+var files, postChecks [][]byte
 
 func NewDispatchCommand() *cobra.Command {
 	dispatchCmd := &cobra.Command{
@@ -28,7 +38,13 @@ func NewDispatchCommand() *cobra.Command {
 Use --debug flag, to log all dispatch replies (Recommended)
 
 # dispatch
-netconf dispatch --host 192.168.1.1 --file dispatch.xml`,
+netconf dispatch --host 192.168.1.1 --file dispatch.xml
+
+# dispatch templated rpc, prints rendered rpc's without connecting
+netconf dispatch --inventory hosts.ini --vars vars.csv --file dispatch.xml --render-only
+
+# dispatch with lock and running config snapshot, snapshot is restored if any rpc or post-check fails
+netconf dispatch --host 192.168.1.1 --file dispatch.xml --lock --snapshot-dir snapshots`,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg, err := config.ParseConfig(context.Background())
@@ -36,12 +52,35 @@ netconf dispatch --host 192.168.1.1 --file dispatch.xml`,
 				log.Fatalf("Failed to init config, error: %v", err)
 			}
 
+			if opts.output != "" {
+				if err := output.Validate(opts.output); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if opts.snapshotDir != "" && !opts.useLock {
+				log.Fatal("Snapshot directory --snapshot-dir must be specified with --lock, snapshot is taken and restored under datastore lock")
+			}
+
 			f, err := utils.ReadFilesFromUser(opts.file)
 			if err != nil {
 				log.Fatalf("Failed to read rpc's, error: %v", err)
 			}
 			files = f
 
+			if opts.renderOnly {
+				if err := render.Print(cfg, files); err != nil {
+					log.Fatalf("Failed to render rpc's, error: %v", err)
+				}
+				return
+			}
+
+			if opts.postCheck != "" {
+				postChecks, err = utils.ReadFilesFromUser(opts.postCheck)
+				if err != nil {
+					log.Fatalf("Failed to read post-check rpc's, error: %v", err)
+				}
+			}
+
 			if err := parallel.RunParallel(cfg, runDispatch); err != nil {
 				log.Fatalf("Failed to execute dispatch")
 			}
@@ -50,6 +89,12 @@ netconf dispatch --host 192.168.1.1 --file dispatch.xml`,
 	flags := dispatchCmd.Flags()
 	flags.StringVarP(&opts.file, "file", "f", "", "stdin, file or directory containing xml files")
 	flags.BoolVarP(&opts.useLock, "lock", "l", false, "run with datastore lock")
+	flags.StringVar(&opts.snapshotDir, "snapshot-dir", "", "save running config to directory before changes, restore it if any step fails, requires --lock")
+	lock.AddFlags(flags, &opts.lock)
+	lock.AddPartialFlags(flags, &opts.lock)
+	flags.BoolVar(&opts.renderOnly, "render-only", false, "print rendered payloads without connecting to devices")
+	flags.StringVar(&opts.postCheck, "post-check", "", "file or directory containing rpc's executed after changes, failure restores snapshot")
+	flags.StringVarP(&opts.output, "output", "o", "", "write replies to stdout in format, xml|json|yaml, replies are logged only with --debug if not set")
 
 	return dispatchCmd
 }
@@ -67,39 +112,121 @@ func runDispatch(device *config.Device, session *netconf.Session) error {
 		datastore = netconf.Candidate
 	}
 
-	start := time.Now()
-	for _, data := range files {
-		if opts.useLock {
-			device.Log.Debugf("Locking %s datastore", datastore)
-			if err := session.Lock(ctx, datastore); err != nil {
-				return err
-			}
+	payloads, err := render.RenderAll(device, files)
+	if err != nil {
+		return err
+	}
+	checks, err := render.RenderAll(device, postChecks)
+	if err != nil {
+		return err
+	}
 
-			if reply, err := session.Dispatch(ctx, data); err != nil {
-				return err
-			} else {
-				replyString := utils.FormatXML(reply.String())
-				device.Log.Debugf("Dispatch reply:\n%s", replyString)
+	var snap *snapshot.Snapshot
+	if opts.snapshotDir != "" {
+		// snapshot is taken, changed and restored under same datastore lock
+		unlock, err := lock.Hold(ctx, device, session, datastore, opts.lock)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := unlock(ctx); err != nil {
+				device.Log.Warnf("Failed to unlock %s datastore: %v", datastore, err)
 			}
+		}()
 
-			device.Log.Debug("Committing changes")
-			if err := session.Commit(ctx); err != nil {
-				return err
-			}
+		s, err := snapshot.Take(ctx, device, session, opts.snapshotDir)
+		if err != nil {
+			return err
+		}
+		snap = s
+	}
 
-			device.Log.Debugf("Unlocking %s datastore", datastore)
-			if err := session.Unlock(ctx, datastore); err != nil {
+	if err := dispatch(ctx, device, session, datastore, payloads, checks, snap != nil); err != nil {
+		if snap != nil {
+			_ = snap.Restore(device, session)
+		}
+		return err
+	}
+	return nil
+}
+
+// dispatch executes payloads, each under own lock with --lock, unless locked is set, when datastore lock is held by caller.
+func dispatch(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, payloads, checks [][]byte, locked bool) error {
+	// one writer per device, so that replies of all rpc's are appended to same output file
+	writer := output.NewWriter(device, "dispatch", opts.output, false)
+
+	start := time.Now()
+	for _, data := range payloads {
+		if opts.useLock {
+			if err := dispatchLocked(ctx, device, session, datastore, writer, data, locked); err != nil {
 				return err
 			}
 		} else {
 			if reply, err := session.Dispatch(ctx, data); err != nil {
 				return err
 			} else {
-				replyString := utils.FormatXML(reply.String())
-				device.Log.Debugf("Dispatch reply:\n%s", replyString)
+				logReply(device, session, writer, reply)
 			}
 		}
 	}
-	device.Log.Infof("Executed %d dispatch requests, took %.3f seconds", len(files), time.Since(start).Seconds())
+	device.Log.Infof("Executed %d dispatch requests, took %.3f seconds", len(payloads), time.Since(start).Seconds())
+
+	for _, data := range checks {
+		reply, err := session.Dispatch(ctx, data)
+		if err != nil {
+			device.Log.Errorf("Post-check failed: %v", err)
+			return err
+		}
+		output.Collect(device, "post-check", reply.String())
+	}
+	if len(checks) > 0 {
+		device.Log.Infof("Executed %d post-check requests", len(checks))
+	}
 	return nil
 }
+
+// dispatchLocked dispatches and commits payload under lock, lock is released also when any step fails.
+func dispatchLocked(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, writer *output.Writer, data []byte, locked bool) (err error) {
+	if !locked {
+		var unlock lock.Unlock
+		if unlock, err = lock.Acquire(ctx, device, session, datastore, data, opts.lock); err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := unlock(ctx); unlockErr != nil && err == nil {
+				err = unlockErr
+			}
+		}()
+	}
+
+	if reply, err := session.Dispatch(ctx, data); err != nil {
+		return err
+	} else {
+		logReply(device, session, writer, reply)
+	}
+
+	device.Log.Debug("Committing changes")
+	if err := session.Commit(ctx); err != nil {
+		return err
+	}
+	output.CollectOK(device, "commit")
+	return nil
+}
+
+// logReply writes reply in output format to stdout, or logs it as xml with debug level, if output is not set.
+// Replies are collected to aggregated document also without output format.
+func logReply(device *config.Device, session *netconf.Session, writer *output.Writer, reply *netconf.Reply) {
+	if opts.output == "" {
+		output.Collect(device, "dispatch", reply.String())
+		device.Log.Debugf("Dispatch reply:\n%s", utils.FormatXML(reply.String()))
+		return
+	}
+	replyString, err := output.Format(reply.String(), opts.output, output.Modules(session.ServerCapabilities()))
+	if err != nil {
+		device.Log.Warnf("Failed to format dispatch reply: %v", err)
+		return
+	}
+	if err := writer.Write(replyString); err != nil {
+		device.Log.Warnf("Failed to write dispatch reply: %v", err)
+	}
+}
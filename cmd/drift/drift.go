@@ -0,0 +1,174 @@
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+const (
+	StatusDrift     = "drift"
+	StatusCompliant = "compliant"
+
+	// ExitDrift is exit code, when any device has drifted from golden config
+	ExitDrift = 2
+
+	groupVar    = "group"
+	defaultFile = "default.xml"
+)
+
+var opts struct {
+	golden  string
+	filters string
+	strip   []string
+}
+
+func NewDriftCommand() *cobra.Command {
+	driftCmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Detect drift from golden configs",
+		Long: `Compare running config of devices to golden configs, reporting added, removed and changed paths.
+
+Golden config of device is first found from --golden directory:
+  <ip>.xml       per-device golden config, e.g. backup repository
+  <group>.xml    per-group golden config, group is read from device variable "group"
+  default.xml    golden config of all other devices
+Golden configs are rendered as templates, see --vars and --template.
+
+Exit code is 2 when any device has drifted and 1 when any device failed.
+
+# detect drift of all devices and write json report
+netconf drift --inventory hosts.ini --golden golden --report drift.json
+
+# detect drift of filtered config
+netconf drift --host 192.168.1.1 --golden golden --filter filter.xml`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			if opts.filters != "" {
+				opts.filters, err = utils.ReadFiltersFromUser(opts.filters)
+				if err != nil {
+					log.Fatalf("Failed to read filters, error: %v", err)
+				}
+			}
+
+			if err := parallel.RunParallel(cfg, runDrift); err != nil {
+				log.Fatalf("Failed to execute drift")
+			}
+
+			var drifted int
+			for _, device := range cfg.Devices {
+				if device.Result.Status == StatusDrift {
+					drifted++
+				}
+			}
+			if drifted > 0 {
+				log.Warnf("Drift found on %d devices", drifted)
+				os.Exit(ExitDrift)
+			}
+		},
+	}
+	flags := driftCmd.Flags()
+	flags.StringVar(&opts.golden, "golden", "", "directory containing golden configs")
+	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, file containing filters")
+	flags.StringSliceVar(&opts.strip, "strip", nil, "element and attribute names ignored in comparison")
+	_ = driftCmd.MarkFlagRequired("golden")
+
+	return driftCmd
+}
+
+// goldenFile finds golden config of device, per-device file is preferred over group and default files.
+func goldenFile(device *config.Device) (string, error) {
+	candidates := []string{device.IP + ".xml"}
+	if group, found := device.Vars[groupVar]; found {
+		candidates = append(candidates, fmt.Sprintf("%v.xml", group))
+	}
+	candidates = append(candidates, defaultFile)
+
+	for _, name := range candidates {
+		path := filepath.Join(opts.golden, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no golden config found from %s, tried %s", opts.golden, strings.Join(candidates, ", "))
+}
+
+func runDrift(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	path, err := goldenFile(device)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden config, error: %v", err)
+	}
+	if data, err = render.Render(device, data); err != nil {
+		return err
+	}
+	nodes, err := xmltree.ParseReply(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse golden config %s, error: %v", path, err)
+	}
+	golden := xmltree.Strip(xmltree.Unwrap(nodes, "config"), opts.strip)
+	device.Result.SetDetail("golden", path)
+
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	reply, err := session.GetConfig(ctx, netconf.Running, netconf.WithSubtreeFilter(string(filter)))
+	if err != nil {
+		return fmt.Errorf("failed to get running config, ip: %s, error: %v", device.IP, err)
+	}
+	nodes, err = xmltree.ParseReply(reply.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse running config, error: %v", err)
+	}
+	running := xmltree.Strip(nodes, opts.strip)
+
+	changes := xmltree.Diff(golden, running)
+	if len(changes) == 0 {
+		device.Result.Status = StatusCompliant
+		device.Log.Infof("Running config matches golden config %s", path)
+	} else {
+		counts := make(map[string]int)
+		var out strings.Builder
+		for _, change := range changes {
+			counts[change.Kind]++
+			out.WriteString(change.String())
+			out.WriteString("\n")
+		}
+		device.Result.Status = StatusDrift
+		device.Result.Changes = changes
+		device.Result.SetDetail("drift", fmt.Sprintf("%d added, %d removed, %d changed",
+			counts[xmltree.ChangeAdded], counts[xmltree.ChangeRemoved], counts[xmltree.ChangeModified]))
+		device.Log.Warnf("Running config drifted from golden config %s:\n%s", path, out.String())
+	}
+	device.Log.Infof("Executed drift, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
@@ -0,0 +1,82 @@
+package edit_config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/lock"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func NewApplyCommand() *cobra.Command {
+	applyCmd := &cobra.Command{
+		Use:   "apply PLAN",
+		Short: "Execute edit-config rpc with plan",
+		Long: `Execute edit-config rpc with plan created by plan command.
+
+PLAN is plan file applied to all devices, or directory containing <ip>-plan.xml file per device.
+Devices without plan file in directory are skipped.
+
+# apply single plan
+netconf apply 192.168.1.1-plan.xml --host 192.168.1.1
+
+# apply saved plans with snapshot
+netconf apply plans --inventory hosts.ini --snapshot-dir snapshots`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			info, err := os.Stat(args[0])
+			if err != nil {
+				log.Fatalf("Failed to read plan, error: %v", err)
+			}
+			if info.IsDir() {
+				opts.planDir = args[0]
+			} else {
+				f, err := utils.ReadFilesFromUser(args[0])
+				if err != nil {
+					log.Fatalf("Failed to read plan, error: %v", err)
+				}
+				files = f
+			}
+
+			if opts.postCheck != "" {
+				postChecks, err = utils.ReadFilesFromUser(opts.postCheck)
+				if err != nil {
+					log.Fatalf("Failed to read post-check rpc's, error: %v", err)
+				}
+			}
+
+			if err := parallel.RunParallel(cfg, runEditConfig); err != nil {
+				log.Fatalf("Failed to execute apply")
+			}
+		},
+	}
+	flags := applyCmd.Flags()
+	flags.StringVarP(&opts.testOp, "test-option", "t", "", "test-option, test-then-set|set|test-only")
+	flags.BoolVarP(&opts.copy, "copy", "c", false, "run copy-config after rpc's")
+	flags.StringVar(&opts.snapshotDir, "snapshot-dir", "", "save running config to directory before changes, restore it if any step fails, datastore is locked for whole run")
+	lock.AddFlags(flags, &opts.lock)
+	lock.AddPartialFlags(flags, &opts.lock)
+	flags.StringVar(&opts.postCheck, "post-check", "", "file or directory containing rpc's executed after changes, failure restores snapshot")
+
+	return applyCmd
+}
+
+// planFiles reads device plan from plan directory.
+func planFiles(device *config.Device) ([][]byte, error) {
+	path := filepath.Join(opts.planDir, fmt.Sprintf("%s-plan.xml", device.IP))
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	return utils.ReadFilesFromUser(path)
+}
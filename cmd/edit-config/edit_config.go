@@ -2,14 +2,20 @@ package edit_config
 
 import (
 	"context"
+	"fmt"
 	"slices"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf"
 	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/lock"
+	"github.com/networkguild/netconf-cli/pkg/output"
 	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/snapshot"
 	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
 	"github.com/spf13/cobra"
 )
 
@@ -18,9 +24,16 @@ var opts struct {
 	testOp   string
 	file     string
 	copy     bool
+
+	snapshotDir string
+	postCheck   string
+	renderOnly  bool
+	lock        lock.Options
+	ifChanged   bool
+	planDir     string
 }
 
-var files [][]byte
+var files, postChecks [][]byte
 
 func NewEditConfigCommand() *cobra.Command {
 	editConfigCmd := &cobra.Command{
@@ -32,7 +45,20 @@ func NewEditConfigCommand() *cobra.Command {
 netconf edit-config --host 192.168.1.1 --test-option test-then-set --default-operation none --file edit-config.xml
 
 # edit-config without optional options
-netconf edit-config --host 192.168.1.1 --file rpc <- directory used here (probably files should be prefixed with number)`,
+netconf edit-config --host 192.168.1.1 --file rpc <- directory used here (probably files should be prefixed with number)
+
+# edit-config with templated payload, e.g. <host-name>{{.Vars.hostname}}</host-name>, prints rendered payloads without connecting
+netconf edit-config --inventory hosts.ini --vars vars.yaml --file edit-config.xml --render-only
+
+# edit-config only devices, which running config differs from payload, reports changed or unchanged status per device
+netconf edit-config --inventory hosts.ini --file edit-config.xml --if-changed
+
+# edit-config with partial-lock, locked xpath's are derived from payload top-level nodes
+netconf edit-config --host 192.168.1.1 --file edit-config.xml --partial-lock-auto
+netconf edit-config --host 192.168.1.1 --file edit-config.xml --partial-lock /sys:system --ns sys=urn:ietf:params:xml:ns:yang:ietf-system
+
+# edit-config with running config snapshot, snapshot is restored if edit-config or post-check rpc's fail
+netconf edit-config --host 192.168.1.1 --file rpc --snapshot-dir snapshots --post-check checks.xml`,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg, err := config.ParseConfig(context.Background())
@@ -46,6 +72,20 @@ netconf edit-config --host 192.168.1.1 --file rpc <- directory used here (probab
 			}
 			files = f
 
+			if opts.renderOnly {
+				if err := render.Print(cfg, files); err != nil {
+					log.Fatalf("Failed to render rpc's, error: %v", err)
+				}
+				return
+			}
+
+			if opts.postCheck != "" {
+				postChecks, err = utils.ReadFilesFromUser(opts.postCheck)
+				if err != nil {
+					log.Fatalf("Failed to read post-check rpc's, error: %v", err)
+				}
+			}
+
 			if err := parallel.RunParallel(cfg, runEditConfig); err != nil {
 				log.Fatalf("Failed to execute edit-config")
 			}
@@ -56,6 +96,12 @@ netconf edit-config --host 192.168.1.1 --file rpc <- directory used here (probab
 	flags.StringVarP(&opts.defaltOp, "default-operation", "d", "merge", "default-operation, none|merge|remove")
 	flags.StringVarP(&opts.testOp, "test-option", "t", "", "test-option, test-then-set|set|test-only")
 	flags.BoolVarP(&opts.copy, "copy", "c", false, "run copy-config after rpc's")
+	flags.StringVar(&opts.snapshotDir, "snapshot-dir", "", "save running config to directory before changes, restore it if any step fails, datastore is locked for whole run")
+	flags.BoolVar(&opts.ifChanged, "if-changed", false, "compare payloads with running config and skip devices already in desired state")
+	lock.AddFlags(flags, &opts.lock)
+	lock.AddPartialFlags(flags, &opts.lock)
+	flags.BoolVar(&opts.renderOnly, "render-only", false, "print rendered payloads without connecting to devices")
+	flags.StringVar(&opts.postCheck, "post-check", "", "file or directory containing rpc's executed after changes, failure restores snapshot")
 
 	return editConfigCmd
 }
@@ -68,7 +114,6 @@ func runEditConfig(device *config.Device, session *netconf.Session) error {
 		capabilities = session.ServerCapabilities()
 		errorOpt     = netconf.WithErrorStrategy(netconf.StopOnError)
 		candidate    = slices.Contains(capabilities, netconf.CandidateCapability)
-		validate     = slices.Contains(capabilities, netconf.ValidateCapability)
 		startup      = slices.Contains(capabilities, netconf.StartupCapability)
 	)
 	if slices.Contains(capabilities, netconf.RollbackOnErrorCapability) {
@@ -78,49 +123,186 @@ func runEditConfig(device *config.Device, session *netconf.Session) error {
 	if candidate {
 		datastore = netconf.Candidate
 	}
-	start := time.Now()
-	for _, data := range files {
-		device.Log.Debugf("Locking %s datastore", datastore)
-		if err := session.Lock(ctx, datastore); err != nil {
+
+	payloads, err := render.RenderAll(device, files)
+	if err != nil {
+		return err
+	}
+	if opts.planDir != "" {
+		payloads, err = planFiles(device)
+		if err != nil {
 			return err
 		}
+		if len(payloads) == 0 {
+			device.Result.Status = parallel.StatusUnchanged
+			device.Log.Info("No plan found for device, skipping edit-config")
+			return nil
+		}
+	}
+	checks, err := render.RenderAll(device, postChecks)
+	if err != nil {
+		return err
+	}
 
-		if err := session.EditConfig(ctx,
-			datastore,
-			data,
-			errorOpt,
-			netconf.WithDefaultMergeStrategy(netconf.MergeStrategy(opts.defaltOp)),
-			netconf.WithTestStrategy(netconf.TestStrategy(opts.testOp)),
-		); err != nil {
-			device.Log.Errorf("Failed to edit %s config: %v", datastore, err)
+	if opts.ifChanged {
+		changed, err := isChanged(ctx, device, session, payloads)
+		if err != nil {
 			return err
 		}
-
-		if validate {
-			device.Log.Debugf("Validating %s datastore", datastore)
-			if err := session.Validate(ctx, datastore); err != nil {
-				return err
-			}
+		if !changed {
+			device.Result.Status = parallel.StatusUnchanged
+			device.Log.Info("Running config already in desired state, skipping edit-config")
+			return nil
 		}
+	}
 
-		device.Log.Debug("Committing changes")
-		if err := session.Commit(ctx); err != nil {
+	var snap *snapshot.Snapshot
+	if opts.snapshotDir != "" {
+		// snapshot is taken, changed and restored under same datastore lock
+		unlock, err := lock.Hold(ctx, device, session, datastore, opts.lock)
+		if err != nil {
 			return err
 		}
+		defer func() {
+			if err := unlock(ctx); err != nil {
+				device.Log.Warnf("Failed to unlock %s datastore: %v", datastore, err)
+			}
+		}()
 
-		device.Log.Debugf("Unlocking %s datastore", datastore)
-		if err := session.Unlock(ctx, datastore); err != nil {
+		s, err := snapshot.Take(ctx, device, session, opts.snapshotDir)
+		if err != nil {
 			return err
 		}
+		snap = s
+	}
+
+	if err := editConfig(ctx, device, session, datastore, errorOpt, payloads, checks, snap != nil); err != nil {
+		if snap != nil {
+			_ = snap.Restore(device, session)
+		}
+		return err
+	}
+	if opts.ifChanged {
+		device.Result.Status = parallel.StatusChanged
 	}
-	device.Log.Infof("Executed %d edit-config requests, took %.3f seconds", len(files), time.Since(start).Seconds())
 
-	start = time.Now()
+	start := time.Now()
 	if opts.copy && startup && netconf.TestStrategy(opts.testOp) != netconf.TestOnly {
 		if err := session.CopyConfig(ctx, netconf.Running, netconf.Startup); err != nil {
 			return err
 		}
+		output.CollectOK(device, "copy-config")
 		device.Log.Infof("Executed copy-config request, took %.3f seconds", time.Since(start).Seconds())
 	}
 	return nil
 }
+
+// editConfig executes payloads, each under own lock, unless locked is set, when datastore lock is held by caller.
+func editConfig(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, errorOpt netconf.EditConfigOption, payloads, checks [][]byte, locked bool) error {
+	validate := slices.Contains(session.ServerCapabilities(), netconf.ValidateCapability)
+
+	start := time.Now()
+	for _, data := range payloads {
+		if err := editPayload(ctx, device, session, datastore, errorOpt, data, validate, locked); err != nil {
+			return err
+		}
+	}
+	device.Log.Infof("Executed %d edit-config requests, took %.3f seconds", len(payloads), time.Since(start).Seconds())
+
+	for _, data := range checks {
+		reply, err := session.Dispatch(ctx, data)
+		if err != nil {
+			device.Log.Errorf("Post-check failed: %v", err)
+			return err
+		}
+		output.Collect(device, "post-check", reply.String())
+	}
+	if len(checks) > 0 {
+		device.Log.Infof("Executed %d post-check requests", len(checks))
+	}
+	return nil
+}
+
+// editPayload edits, validates and commits payload, lock is released also when any step fails.
+func editPayload(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, errorOpt netconf.EditConfigOption, data []byte, validate, locked bool) (err error) {
+	if !locked {
+		var unlock lock.Unlock
+		if unlock, err = lock.Acquire(ctx, device, session, datastore, data, opts.lock); err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := unlock(ctx); unlockErr != nil && err == nil {
+				err = unlockErr
+			}
+		}()
+	}
+
+	if err := session.EditConfig(ctx,
+		datastore,
+		data,
+		errorOpt,
+		netconf.WithDefaultMergeStrategy(netconf.MergeStrategy(opts.defaltOp)),
+		netconf.WithTestStrategy(netconf.TestStrategy(opts.testOp)),
+	); err != nil {
+		device.Log.Errorf("Failed to edit %s config: %v", datastore, err)
+		return err
+	}
+	output.CollectOK(device, "edit-config")
+
+	if validate {
+		device.Log.Debugf("Validating %s datastore", datastore)
+		if err := session.Validate(ctx, datastore); err != nil {
+			return err
+		}
+	}
+
+	device.Log.Debug("Committing changes")
+	if err := session.Commit(ctx); err != nil {
+		return err
+	}
+	output.CollectOK(device, "commit")
+	return nil
+}
+
+// isChanged compares payloads with running config, fetched using subtree filter derived from payloads.
+func isChanged(ctx context.Context, device *config.Device, session *netconf.Session, payloads [][]byte) (bool, error) {
+	for i, data := range payloads {
+		nodes, err := xmltree.Parse(data)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse payload %d, error: %v", i+1, err)
+		}
+		var (
+			desired = xmltree.Unwrap(nodes, "config")
+			replace = netconf.MergeStrategy(opts.defaltOp) == netconf.MergeStrategy("replace")
+			filter  = xmltree.SubtreeFilter(desired)
+		)
+		if replace {
+			filter = xmltree.RootFilter(desired)
+		}
+
+		reply, err := session.GetConfig(ctx,
+			netconf.Running,
+			netconf.WithSubtreeFilter(xmltree.Marshal(filter)),
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to get running config, error: %v", err)
+		}
+
+		actual, err := xmltree.ParseReply(reply.String())
+		if err != nil {
+			return false, fmt.Errorf("failed to parse running config, error: %v", err)
+		}
+
+		var inSync bool
+		if replace {
+			inSync = xmltree.Equal(actual, desired)
+		} else {
+			inSync = xmltree.Contains(actual, desired)
+		}
+		if !inSync {
+			device.Log.Debugf("Payload %d differs from running config", i+1)
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,247 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/exporter"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/ssh"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	ncssh "github.com/networkguild/netconf/transport/ssh"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	mapping  string
+	listen   string
+	interval time.Duration
+	timeout  time.Duration
+}
+
+var (
+	mapping  *exporter.Mapping
+	registry *exporter.Registry
+	client   *ssh.Client
+)
+
+// target is device with persistent session, reopened on next scrape after failure.
+type target struct {
+	device config.Device
+
+	mu        sync.Mutex
+	transport *ncssh.Transport
+	session   *netconf.Session
+}
+
+func NewExporterCommand() *cobra.Command {
+	exporterCmd := &cobra.Command{
+		Use:   "exporter",
+		Short: "Export netconf data as prometheus metrics",
+		Long: `Serve prometheus metrics from netconf data at /metrics. Mapping file declares metrics with get or get-config filter,
+xpath selecting entries, value and labels evaluated with entry as context node, see examples/exporter.yaml.
+
+Sessions are kept open per device. By default devices are polled on every scrape, with --interval devices are polled
+in background and scrape returns latest values. Every device has netconf_up, netconf_scrape_duration_seconds
+and netconf_scrape_errors_total series.
+
+# serve metrics of inventory devices, polled on scrape
+netconf exporter --inventory hosts.ini --mapping exporter.yaml --listen :9830
+
+# poll devices every 30 seconds
+netconf exporter --inventory hosts.ini --mapping exporter.yaml --interval 30s`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+			var err error
+			mapping, err = exporter.Load(opts.mapping)
+			if err != nil {
+				log.Fatalf("Failed to load mapping, error: %v", err)
+			}
+			cfg, err := config.ParseConfig(ctx)
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			registry = exporter.NewRegistry(mapping)
+			client = ssh.NewClient(len(cfg.Devices), cfg.Multiplexing, true)
+			defer client.Close()
+
+			targets := make([]*target, 0, len(cfg.Devices))
+			for _, device := range cfg.Devices {
+				targets = append(targets, &target{device: device})
+			}
+			defer func() {
+				for _, t := range targets {
+					t.mu.Lock()
+					t.close()
+					t.mu.Unlock()
+				}
+			}()
+
+			if opts.interval > 0 {
+				go func() {
+					ticker := time.NewTicker(opts.interval)
+					defer ticker.Stop()
+					for {
+						scrapeAll(targets)
+						select {
+						case <-ctx.Done():
+							return
+						case <-ticker.C:
+						}
+					}
+				}()
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+				if opts.interval <= 0 {
+					scrapeAll(targets)
+				}
+				w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+				if err := registry.Write(w); err != nil {
+					log.Warnf("Failed to write metrics: %v", err)
+				}
+			})
+			server := &http.Server{Addr: opts.listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+			go func() {
+				sig := <-sigs
+				log.Warnf("Received signal %s, stopping exporter...", sig)
+				cancel()
+				shutdown, done := context.WithTimeout(context.Background(), 5*time.Second)
+				defer done()
+				_ = server.Shutdown(shutdown)
+			}()
+
+			log.Infof("Serving metrics of %d devices at %s/metrics", len(targets), opts.listen)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to serve metrics, error: %v", err)
+			}
+		},
+	}
+	flags := exporterCmd.Flags()
+	flags.StringVar(&opts.mapping, "mapping", "", "yaml file containing metric mappings")
+	flags.StringVar(&opts.listen, "listen", ":9830", "listen address of metrics endpoint")
+	flags.DurationVar(&opts.interval, "interval", 0, "poll devices in background with interval, 0 polls on every scrape")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of polling one device")
+	_ = exporterCmd.MarkFlagRequired("mapping")
+
+	return exporterCmd
+}
+
+func scrapeAll(targets []*target) {
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	for _, t := range targets {
+		go func(t *target) {
+			defer wg.Done()
+			t.scrape()
+		}(t)
+	}
+	wg.Wait()
+}
+
+// scrape polls all requests of mapping from device, concurrent scrapes of same device are serialized.
+func (t *target) scrape() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := time.Now()
+	result := &exporter.Device{IP: t.device.IP, Samples: make(map[*exporter.Metric][]exporter.Sample)}
+	if err := t.poll(result); err != nil {
+		t.device.Log.Errorf("Scrape failed: %v", err)
+		t.close()
+	} else {
+		result.Up = true
+	}
+	result.Duration = time.Since(start)
+	registry.Set(result)
+}
+
+func (t *target) poll(result *exporter.Device) error {
+	if t.session == nil {
+		if err := t.open(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(t.device.Ctx, opts.timeout)
+	defer cancel()
+	for _, request := range mapping.Requests() {
+		filter, err := render.Render(&t.device, []byte(request.Filter))
+		if err != nil {
+			return err
+		}
+
+		var reply *netconf.Reply
+		if request.Source != "" {
+			reply, err = t.session.GetConfig(ctx, netconf.Datastore(request.Source), netconf.WithSubtreeFilter(string(filter)))
+		} else {
+			reply, err = t.session.Get(ctx, netconf.WithSubtreeFilter(string(filter)))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to poll, error: %v", err)
+		}
+		nodes, err := xmltree.ParseReply(reply.String())
+		if err != nil {
+			return fmt.Errorf("failed to parse reply, error: %v", err)
+		}
+
+		for _, metric := range mapping.Metrics {
+			if metric.Request() == request {
+				result.Samples[metric] = metric.Collect(nodes)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *target) open() error {
+	sshClient, err := client.DialSSH(&t.device)
+	if err != nil {
+		return fmt.Errorf("failed to dial ssh, error: %v", err)
+	}
+	t.transport, err = ncssh.NewTransport(sshClient)
+	if err != nil {
+		_ = client.CloseDeviceConn(t.device.IP)
+		return fmt.Errorf("failed to create new transport, error: %v", err)
+	}
+	t.session, err = netconf.Open(t.transport, netconf.WithLogger(t.device.Log))
+	if err != nil {
+		t.close()
+		return fmt.Errorf("failed to exchange hello messages, error: %v", err)
+	}
+	t.device.Log.Debugf("Started netconf session with id: %d", t.session.SessionID())
+	return nil
+}
+
+func (t *target) close() {
+	if t.session != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = t.session.Close(ctx)
+		cancel()
+		t.session = nil
+	}
+	if t.transport != nil {
+		_ = t.transport.Close()
+		t.transport = nil
+		_ = client.CloseDeviceConn(t.device.IP)
+	}
+}
@@ -3,14 +3,18 @@ package get_config
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf"
 	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/output"
 	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/query"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/rpc"
 	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
 	"github.com/spf13/cobra"
 )
 
@@ -18,9 +22,21 @@ var opts struct {
 	filters  string
 	defaults string
 	persist  bool
+	render   bool
 	source   string
+	xpath    string
+	ns       []string
+	paths    []string
+	output   string
+	query    query.Options
 }
 
+var (
+	namespaces map[string]string
+	// table collects selected values of replies, when --select is used
+	table *query.Table
+)
+
 func NewGetConfigCommand() *cobra.Command {
 	getCmd := &cobra.Command{
 		Use:   "get-config",
@@ -31,7 +47,10 @@ func NewGetConfigCommand() *cobra.Command {
 netconf get-config --inventory hosts.ini --save --source startup
 
 # get-config using host flag, prints to stdout
-netconf get-config --host 192.168.1.1 --password pass --username user`,
+netconf get-config --host 192.168.1.1 --password pass --username user
+
+# get-config using xpath filter, device must advertise :xpath capability
+netconf get-config --host 192.168.1.1 --xpath "/sys:system/sys:hostname" --ns sys=urn:ietf:params:xml:ns:yang:ietf-system`,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg, err := config.ParseConfig(context.Background())
@@ -46,7 +65,35 @@ netconf get-config --host 192.168.1.1 --password pass --username user`,
 				}
 			}
 
-			if err := parallel.RunParallel(cfg, runGetConfig); err != nil {
+			if err := output.Validate(opts.output); err != nil {
+				log.Fatal(err)
+			}
+			namespaces, err = config.Namespaces(opts.ns)
+			if err != nil {
+				log.Fatalf("Failed to parse namespaces, error: %v", err)
+			}
+			if table, err = opts.query.Table(namespaces); err != nil {
+				log.Fatal(err)
+			}
+			if len(opts.paths) > 0 {
+				opts.filters, err = xmltree.PathFilter(opts.paths, namespaces)
+				if err != nil {
+					log.Fatalf("Failed to build filter from paths, error: %v", err)
+				}
+			}
+
+			if opts.render {
+				if err := render.Print(cfg, [][]byte{[]byte(opts.filters + opts.xpath)}); err != nil {
+					log.Fatalf("Failed to render filters, error: %v", err)
+				}
+				return
+			}
+
+			err = parallel.RunParallel(cfg, runGetConfig)
+			if table != nil {
+				table.Print(cfg.Devices, opts.query.CSV)
+			}
+			if err != nil {
 				log.Fatalf("Failed to execute get-config")
 			}
 		},
@@ -55,6 +102,13 @@ netconf get-config --host 192.168.1.1 --password pass --username user`,
 	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, stdin or file containing filters")
 	flags.StringVarP(&opts.defaults, "with-defaults", "d", "", "with-defaults option, report-all|report-all-tagged|trim|explicit")
 	flags.BoolVar(&opts.persist, "save", false, "save output to file, default name is used, if no suffix provided")
+	flags.BoolVar(&opts.render, "render-only", false, "print rendered filters without connecting to devices")
+	flags.StringVarP(&opts.output, "output", "o", output.FormatXML, "output format, xml|json|yaml")
+	query.AddFlags(flags, &opts.query)
+	flags.StringVar(&opts.xpath, "xpath", "", "xpath filter expression, requires :xpath capability")
+	flags.StringSliceVar(&opts.ns, "ns", nil, "namespace prefix mapping for xpath filter and paths, prefix=uri")
+	flags.StringArrayVar(&opts.paths, "path", nil, "path to build subtree filter from, e.g. /prefix:state/port[port-id=1/1/1], repeatable")
+	getCmd.MarkFlagsMutuallyExclusive("filter", "xpath", "path")
 	flags.StringVarP(&opts.source, "source", "s", "running", "running|candidate|startup")
 
 	return getCmd
@@ -64,37 +118,56 @@ func runGetConfig(device *config.Device, session *netconf.Session) error {
 	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
 	defer cancel()
 
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
 	start := time.Now()
-	reply, err := session.GetConfig(ctx,
-		netconf.Datastore(opts.source),
-		netconf.WithDefaultMode(netconf.DefaultsMode(opts.defaults)),
-		netconf.WithSubtreeFilter(opts.filters),
-	)
+	var reply *netconf.Reply
+	if opts.xpath != "" {
+		reply, err = getConfigXPath(ctx, device, session)
+	} else {
+		reply, err = session.GetConfig(ctx,
+			netconf.Datastore(opts.source),
+			netconf.WithDefaultMode(netconf.DefaultsMode(opts.defaults)),
+			netconf.WithSubtreeFilter(string(filter)),
+		)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get %s config, ip: %s, error: %v", opts.source, device.IP, err)
 	}
 
-	replyString := utils.FormatXML(reply.String())
-	if opts.persist {
-		var name string
-		if device.Suffix != "" {
-			name = fmt.Sprintf("%s-%s", device.IP, device.Suffix)
-		} else {
-			name = fmt.Sprintf("%s-get-config-%s.xml", device.IP, utils.TimeStamp())
+	// with --select, reply is written only when it is persisted
+	if table != nil {
+		if err := table.AddReply(device.IP, reply.String()); err != nil {
+			return err
 		}
-
-		file, err := os.Create(name)
-		if err != nil {
-			device.Log.Errorf("Failed to create file: %v", err)
-		} else {
-			defer file.Close()
-
-			file.WriteString(replyString)
-			device.Log.Infof("Saved get reply to file %s", name)
+		if !output.Persisted(opts.persist) {
+			device.Log.Infof("Executed get-config request, took %.3f seconds", time.Since(start).Seconds())
+			return nil
 		}
-	} else {
-		device.Log.Infof("Get-config reply:\n%s", replyString)
+	}
+
+	replyString, err := output.Format(reply.String(), opts.output, output.Modules(session.ServerCapabilities()))
+	if err != nil {
+		return err
+	}
+	if err := output.NewWriter(device, "get-config", opts.output, opts.persist).Write(replyString); err != nil {
+		return err
 	}
 	device.Log.Infof("Executed get-config request, took %.3f seconds", time.Since(start).Seconds())
 	return nil
 }
+
+// getConfigXPath dispatches get-config with xpath filter, device must advertise :xpath capability.
+func getConfigXPath(ctx context.Context, device *config.Device, session *netconf.Session) (*netconf.Reply, error) {
+	if err := rpc.CheckXPath(session); err != nil {
+		return nil, err
+	}
+	expr, err := render.Render(device, []byte(opts.xpath))
+	if err != nil {
+		return nil, err
+	}
+	return session.Dispatch(ctx, rpc.GetConfig(opts.source, rpc.XPathFilter(string(expr), namespaces), opts.defaults))
+}
@@ -2,15 +2,18 @@ package get
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf"
 	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/output"
 	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/query"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/rpc"
 	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
 	"github.com/spf13/cobra"
 )
 
@@ -18,8 +21,20 @@ var opts struct {
 	filters  string
 	defaults string
 	persist  bool
+	render   bool
+	xpath    string
+	ns       []string
+	paths    []string
+	output   string
+	query    query.Options
 }
 
+var (
+	namespaces map[string]string
+	// table collects selected values of replies, when --select is used
+	table *query.Table
+)
+
 func NewGetCommand() *cobra.Command {
 	getCmd := &cobra.Command{
 		Use:   "get",
@@ -32,6 +47,9 @@ echo "<devm xmlns=\"urn:huawei:yang:huawei-devm\"></devm>" | netconf get --inven
 # get using env values and filter file, prints to stdout
 netconf get --host 192.168.1.1 --filters filters.xml --debug
 
+# get using xpath filter, device must advertise :xpath capability
+netconf get --host 192.168.1.1 --xpath "/if:interfaces/if:interface[if:enabled='false']" --ns if=urn:ietf:params:xml:ns:yang:ietf-interfaces
+
 Example filters file content:
 # filters.xml 
 <state xmlns="urn:nokia.com:sros:ns:yang:sr:state">
@@ -58,7 +76,35 @@ All filters are fetched same time.`,
 				}
 			}
 
-			if err := parallel.RunParallel(cfg, runGet); err != nil {
+			if err := output.Validate(opts.output); err != nil {
+				log.Fatal(err)
+			}
+			namespaces, err = config.Namespaces(opts.ns)
+			if err != nil {
+				log.Fatalf("Failed to parse namespaces, error: %v", err)
+			}
+			if table, err = opts.query.Table(namespaces); err != nil {
+				log.Fatal(err)
+			}
+			if len(opts.paths) > 0 {
+				opts.filters, err = xmltree.PathFilter(opts.paths, namespaces)
+				if err != nil {
+					log.Fatalf("Failed to build filter from paths, error: %v", err)
+				}
+			}
+
+			if opts.render {
+				if err := render.Print(cfg, [][]byte{[]byte(opts.filters + opts.xpath)}); err != nil {
+					log.Fatalf("Failed to render filters, error: %v", err)
+				}
+				return
+			}
+
+			err = parallel.RunParallel(cfg, runGet)
+			if table != nil {
+				table.Print(cfg.Devices, opts.query.CSV)
+			}
+			if err != nil {
 				log.Fatalf("Failed to execute get")
 			}
 		},
@@ -67,6 +113,13 @@ All filters are fetched same time.`,
 	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, stdin or file containing filters")
 	flags.StringVarP(&opts.defaults, "with-defaults", "d", "", "with-defaults option, report-all|report-all-tagged|trim|explicit")
 	flags.BoolVar(&opts.persist, "save", false, "save output to file, default name is used, if no suffix provided")
+	flags.BoolVar(&opts.render, "render-only", false, "print rendered filters without connecting to devices")
+	flags.StringVarP(&opts.output, "output", "o", output.FormatXML, "output format, xml|json|yaml")
+	query.AddFlags(flags, &opts.query)
+	flags.StringVar(&opts.xpath, "xpath", "", "xpath filter expression, requires :xpath capability")
+	flags.StringSliceVar(&opts.ns, "ns", nil, "namespace prefix mapping for xpath filter and paths, prefix=uri")
+	flags.StringArrayVar(&opts.paths, "path", nil, "path to build subtree filter from, e.g. /prefix:state/port[port-id=1/1/1], repeatable")
+	getCmd.MarkFlagsMutuallyExclusive("filter", "xpath", "path")
 
 	return getCmd
 }
@@ -75,37 +128,59 @@ func runGet(device *config.Device, session *netconf.Session) error {
 	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
 	defer cancel()
 
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
 	start := time.Now()
-	reply, err := session.Get(ctx,
-		netconf.WithDefaultMode(netconf.DefaultsMode(opts.defaults)),
-		netconf.WithSubtreeFilter(opts.filters),
-	)
+	var reply *netconf.Reply
+	if opts.xpath != "" {
+		reply, err = getXPath(ctx, device, session)
+	} else {
+		reply, err = session.Get(ctx,
+			netconf.WithDefaultMode(netconf.DefaultsMode(opts.defaults)),
+			netconf.WithSubtreeFilter(string(filter)),
+		)
+	}
 	if err != nil {
 		device.Log.Errorf("Failed to get subtree: %v", err)
 		return err
 	}
 
-	replyString := utils.FormatXML(reply.String())
-	if opts.persist {
-		var name string
-		if device.Suffix != "" {
-			name = fmt.Sprintf("%s-%s", device.IP, device.Suffix)
-		} else {
-			name = fmt.Sprintf("%s-get-filters.xml", device.IP)
-		}
-
-		file, err := os.Create(name)
-		if err != nil {
-			device.Log.Errorf("Failed to create file: %v", err)
-		} else {
-			defer file.Close()
-
-			file.WriteString(replyString)
-			device.Log.Infof("Saved get reply to file %s", name)
-		}
-	} else {
-		device.Log.Infof("Get reply:\n%s", replyString)
+	if err := writeReply(device, session, reply.String(), opts.output, opts.persist, "get"); err != nil {
+		return err
 	}
 	device.Log.Infof("Executed get filter request, took %.3f seconds", time.Since(start).Seconds())
 	return nil
 }
+
+// writeReply writes reply converted to format to stdout, or to file, when persist is set.
+// With --select, selected values are collected to table and reply is written only when it is persisted.
+func writeReply(device *config.Device, session *netconf.Session, reply, format string, persist bool, command string) error {
+	if table != nil {
+		if err := table.AddReply(device.IP, reply); err != nil {
+			return err
+		}
+		if !output.Persisted(persist) {
+			return nil
+		}
+	}
+	replyString, err := output.Format(reply, format, output.Modules(session.ServerCapabilities()))
+	if err != nil {
+		return err
+	}
+	return output.NewWriter(device, command, format, persist).Write(replyString)
+}
+
+// getXPath dispatches get with xpath filter, device must advertise :xpath capability.
+func getXPath(ctx context.Context, device *config.Device, session *netconf.Session) (*netconf.Reply, error) {
+	if err := rpc.CheckXPath(session); err != nil {
+		return nil, err
+	}
+	expr, err := render.Render(device, []byte(opts.xpath))
+	if err != nil {
+		return nil, err
+	}
+	return session.Dispatch(ctx, rpc.Get(rpc.XPathFilter(string(expr), namespaces), opts.defaults))
+}
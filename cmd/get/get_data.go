@@ -0,0 +1,178 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/output"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/query"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/rpc"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var dataOpts struct {
+	datastore     string
+	filters       string
+	xpath         string
+	ns            []string
+	configFilter  string
+	maxDepth      int
+	withOrigin    bool
+	originFilter  []string
+	negatedOrigin []string
+	defaults      string
+	persist       bool
+	render        bool
+	output        string
+	query         query.Options
+}
+
+var dataNamespaces map[string]string
+
+func NewGetDataCommand() *cobra.Command {
+	getDataCmd := &cobra.Command{
+		Use:   "get-data",
+		Short: "Execute get-data rpc",
+		Long: `Execute get-data rpc (RFC 8526) for retrieving data from nmda datastore, e.g. operational or intended.
+
+Datastore support is discovered from yang-library of device, before get-data is sent.
+
+# get operational interfaces state with origin metadata
+echo "<interfaces xmlns=\"urn:ietf:params:xml:ns:yang:ietf-interfaces\"/>" | netconf get-data --host 192.168.1.1 --with-origin
+
+# get intended config, only nodes learned from protocols, max two levels deep
+netconf get-data --host 192.168.1.1 --datastore intended --origin-filter learned --max-depth 2
+
+# get operational data with xpath filter, writes to file
+netconf get-data --inventory hosts.ini --xpath "/if:interfaces/if:interface[if:name='eth0']" --ns if=urn:ietf:params:xml:ns:yang:ietf-interfaces --save`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			if dataOpts.filters != "" {
+				dataOpts.filters, err = utils.ReadFiltersFromUser(dataOpts.filters)
+				if err != nil {
+					log.Fatalf("Failed to read filters, error: %v", err)
+				}
+			}
+			if err := output.Validate(dataOpts.output); err != nil {
+				log.Fatal(err)
+			}
+			dataNamespaces, err = config.Namespaces(dataOpts.ns)
+			if err != nil {
+				log.Fatalf("Failed to parse namespaces, error: %v", err)
+			}
+			if table, err = dataOpts.query.Table(dataNamespaces); err != nil {
+				log.Fatal(err)
+			}
+			if !slices.Contains([]string{"", "true", "false"}, dataOpts.configFilter) {
+				log.Fatalf("Invalid config-filter %s, must be true or false", dataOpts.configFilter)
+			}
+
+			if dataOpts.render {
+				if err := render.Print(cfg, [][]byte{[]byte(dataOpts.filters + dataOpts.xpath)}); err != nil {
+					log.Fatalf("Failed to render filters, error: %v", err)
+				}
+				return
+			}
+
+			err = parallel.RunParallel(cfg, runGetData)
+			if table != nil {
+				table.Print(cfg.Devices, dataOpts.query.CSV)
+			}
+			if err != nil {
+				log.Fatalf("Failed to execute get-data")
+			}
+		},
+	}
+	flags := getDataCmd.Flags()
+	flags.StringVar(&dataOpts.datastore, "datastore", "operational", "nmda datastore, e.g. running|candidate|startup|intended|operational")
+	flags.StringVarP(&dataOpts.filters, "filter", "f", "", "subtree filter option, stdin or file containing filters")
+	flags.StringVar(&dataOpts.xpath, "xpath", "", "xpath filter expression, requires :xpath capability")
+	flags.StringSliceVar(&dataOpts.ns, "ns", nil, "namespace prefix mapping for xpath filter and origin identities, prefix=uri")
+	flags.StringVar(&dataOpts.configFilter, "config-filter", "", "true returns only config nodes, false only non-config nodes")
+	flags.IntVar(&dataOpts.maxDepth, "max-depth", 0, "maximum depth of returned subtrees, 0 is unbounded")
+	flags.BoolVar(&dataOpts.withOrigin, "with-origin", false, "return origin metadata, operational datastore only")
+	flags.StringSliceVar(&dataOpts.originFilter, "origin-filter", nil, "return only nodes with origin, e.g. intended|learned|system|default")
+	flags.StringSliceVar(&dataOpts.negatedOrigin, "negated-origin-filter", nil, "return only nodes without origin")
+	flags.StringVarP(&dataOpts.defaults, "with-defaults", "d", "", "with-defaults option, report-all|report-all-tagged|trim|explicit")
+	flags.BoolVar(&dataOpts.persist, "save", false, "save output to file, default name is used, if no suffix provided")
+	flags.BoolVar(&dataOpts.render, "render-only", false, "print rendered filters without connecting to devices")
+	flags.StringVarP(&dataOpts.output, "output", "o", output.FormatXML, "output format, xml|json|yaml")
+	query.AddFlags(flags, &dataOpts.query)
+	getDataCmd.MarkFlagsMutuallyExclusive("filter", "xpath")
+	getDataCmd.MarkFlagsMutuallyExclusive("origin-filter", "negated-origin-filter")
+
+	return getDataCmd
+}
+
+func runGetData(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	datastores, err := rpc.Datastores(ctx, session)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(datastores, dataOpts.datastore) {
+		return fmt.Errorf("datastore %s not supported, device supports %s", dataOpts.datastore, strings.Join(datastores, ", "))
+	}
+	if dataOpts.withOrigin && dataOpts.datastore != "operational" {
+		return fmt.Errorf("with-origin is supported only with operational datastore")
+	}
+
+	request := rpc.GetData{
+		Datastore:     dataOpts.datastore,
+		Namespaces:    dataNamespaces,
+		ConfigFilter:  dataOpts.configFilter,
+		MaxDepth:      dataOpts.maxDepth,
+		WithOrigin:    dataOpts.withOrigin,
+		OriginFilter:  dataOpts.originFilter,
+		NegatedOrigin: len(dataOpts.negatedOrigin) > 0,
+		Defaults:      dataOpts.defaults,
+	}
+	if request.NegatedOrigin {
+		request.OriginFilter = dataOpts.negatedOrigin
+	}
+
+	if dataOpts.xpath != "" {
+		if err := rpc.CheckXPath(session); err != nil {
+			return err
+		}
+		expr, err := render.Render(device, []byte(dataOpts.xpath))
+		if err != nil {
+			return err
+		}
+		request.XPath = string(expr)
+	} else {
+		filter, err := render.Render(device, []byte(dataOpts.filters))
+		if err != nil {
+			return err
+		}
+		request.Filter = string(filter)
+	}
+
+	start := time.Now()
+	reply, err := session.Dispatch(ctx, request.Request())
+	if err != nil {
+		return fmt.Errorf("failed to get %s data, ip: %s, error: %v", dataOpts.datastore, device.IP, err)
+	}
+
+	if err := writeReply(device, session, reply.String(), dataOpts.output, dataOpts.persist, "get-data"); err != nil {
+		return err
+	}
+	device.Log.Infof("Executed get-data request, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
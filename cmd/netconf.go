@@ -2,16 +2,28 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/mitchellh/go-homedir"
+	"github.com/networkguild/netconf-cli/cmd/backup"
+	"github.com/networkguild/netconf-cli/cmd/check"
+	"github.com/networkguild/netconf-cli/cmd/compliance"
 	copyconfig "github.com/networkguild/netconf-cli/cmd/copy-config"
+	"github.com/networkguild/netconf-cli/cmd/diff"
 	"github.com/networkguild/netconf-cli/cmd/dispatch"
+	"github.com/networkguild/netconf-cli/cmd/drift"
 	editconfig "github.com/networkguild/netconf-cli/cmd/edit-config"
+	"github.com/networkguild/netconf-cli/cmd/exporter"
 	"github.com/networkguild/netconf-cli/cmd/get"
 	getconfig "github.com/networkguild/netconf-cli/cmd/get-config"
 	"github.com/networkguild/netconf-cli/cmd/notification"
+	"github.com/networkguild/netconf-cli/cmd/plan"
+	"github.com/networkguild/netconf-cli/cmd/query"
+	"github.com/networkguild/netconf-cli/cmd/restore"
+	"github.com/networkguild/netconf-cli/cmd/watch"
+	"github.com/networkguild/netconf-cli/pkg/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -21,6 +33,7 @@ var opts struct {
 	trace   bool
 	caller  bool
 	logfile string
+	config  string
 
 	noMultiplexing bool
 }
@@ -31,18 +44,33 @@ var (
 		Short: "Cli tool for running netconf operations",
 		Long: `Cli tool for running netconf operations on network devices.
 
-Supported netconf operations are get-config, get, edit-config, copy-config, notifications and custom dispatch.
+Supported netconf operations are get-config, get, get-data, edit-config, copy-config, notifications and custom dispatch.
+Plan and apply compute and execute edit-config from desired config, backup and restore archive configs to git repository and push them back,
+diff and drift compare configs semantically,
+compliance checks configs against xpath rules, query selects values from saved replies,
+watch polls devices and prints changes between samples, exporter serves netconf data as prometheus metrics,
+check runs as nagios plugin.
 
 All commands support parallel run with multiple devices, --inventory, -i file is needed with multiple hosts.
 
 Global flags can be configured via environment variables (prefix NETCONF) or via command-line flags
 
+Replies are written to stdout and logs to stderr, use --ndjson to wrap replies per device or --output-dir to save them to files
+
 If you want trace all incoming and outgoing RPC's, set NETCONF_DEBUG_CAPTURE_DIR environment variable or use --trace flag,
 this will save all incoming RPC's to file <currect-time>.in and outgoing RPC's to <currect-time>.out. 
 RPC's are saved in raw format, including chunked markers.
 `,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			if cmd.Name() != "help" {
+				if err := readConfigFile(opts.config); err != nil {
+					log.Fatalf("Failed to read config file, error: %v", err)
+				}
+
+				if err := output.Setup(viper.GetString("output-dir"), viper.GetBool("ndjson"), viper.GetString("aggregate") != ""); err != nil {
+					log.Fatal(err)
+				}
+
 				if opts.caller {
 					log.SetReportCaller(opts.caller)
 				}
@@ -132,7 +160,8 @@ $ netconf completion fish > ~/.config/fish/completions/netconf.fish
 )
 
 func init() {
-	logger := log.NewWithOptions(os.Stdout, log.Options{
+	// replies are written to stdout, logs to stderr
+	logger := log.NewWithOptions(os.Stderr, log.Options{
 		ReportTimestamp: true,
 		TimeFormat:      time.TimeOnly,
 		Prefix:          "netconf",
@@ -141,11 +170,23 @@ func init() {
 	rootCmd.AddCommand(
 		completionCmd,
 		get.NewGetCommand(),
+		get.NewGetDataCommand(),
 		getconfig.NewGetConfigCommand(),
 		editconfig.NewEditConfigCommand(),
 		notification.NewNotificationCommand(),
 		dispatch.NewDispatchCommand(),
 		copyconfig.NewCopyConfigCommand(),
+		plan.NewPlanCommand(),
+		editconfig.NewApplyCommand(),
+		backup.NewBackupCommand(),
+		restore.NewRestoreCommand(),
+		diff.NewDiffCommand(),
+		drift.NewDriftCommand(),
+		compliance.NewComplianceCommand(),
+		query.NewQueryCommand(),
+		watch.NewWatchCommand(),
+		exporter.NewExporterCommand(),
+		check.NewCheckCommand(),
 	)
 	persistentFlags := rootCmd.PersistentFlags()
 	persistentFlags.StringP("username", "u", "admin", "SSH username or env NETCONF_USERNAME")
@@ -158,6 +199,13 @@ func init() {
 	persistentFlags.StringVar(&opts.logfile, "logfile", "", "Enables logging to specific file")
 	persistentFlags.StringP("inventory", "i", "", "Inventory file containing IP's")
 	persistentFlags.StringSlice("host", []string{}, "IP or IP's of devices to connect")
+	persistentFlags.String("vars", "", "Csv or yaml file containing per-device template variables")
+	persistentFlags.Bool("template", false, "Renders payloads and filters as templates, enabled also by --vars and inventory variables")
+	persistentFlags.String("report", "", "Writes json report of device results to file")
+	persistentFlags.String("output-dir", "", "Saves replies to files, directory or file name template, e.g. {{.Host}}/{{.Command}}-{{.Date}}.xml")
+	persistentFlags.Bool("ndjson", false, "Writes replies to stdout as json lines with host, command and timestamp")
+	persistentFlags.String("aggregate", "", "Writes replies of all devices to one xml or json document, format by file extension")
+	persistentFlags.StringVar(&opts.config, "config", "", "Config file for global flags and namespace prefixes. Default $HOME/.netconf/config.yaml")
 	rootCmd.MarkFlagsMutuallyExclusive("inventory", "host")
 	if err := viper.BindPFlags(persistentFlags); err != nil {
 		log.Fatalf("Failed to bind cobra persistentFlags to viper, error: %v", err)
@@ -166,6 +214,22 @@ func init() {
 	viper.AutomaticEnv()
 }
 
+// readConfigFile reads yaml config file, default config file is read only if it exists.
+func readConfigFile(path string) error {
+	if path == "" {
+		dir, err := homedir.Dir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(dir, ".netconf", "config.yaml")
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+	}
+	viper.SetConfigFile(path)
+	return viper.ReadInConfig()
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
@@ -12,9 +14,11 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf"
 	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/output"
+	"github.com/networkguild/netconf-cli/pkg/rpc"
+	"github.com/networkguild/netconf-cli/pkg/sink"
 	"github.com/networkguild/netconf-cli/pkg/ssh"
 	"github.com/networkguild/netconf-cli/pkg/utils"
-	ncssh "github.com/networkguild/netconf/transport/ssh"
 	"github.com/spf13/cobra"
 )
 
@@ -23,14 +27,57 @@ var opts struct {
 	persist    bool
 	stream     string
 	duration   time.Duration
+	output     string
+
+	establish bool
+	datastore string
+	filters   string
+	xpath     string
+	ns        []string
+	period    time.Duration
+	onChange  bool
+	dampening time.Duration
+	modify    string
+	delete    string
+	kill      bool
+	reconnect bool
+	startTime string
+	stopTime  string
+	events    []string
+	match     string
+	sinks     string
 }
 
+var (
+	// startTime and stopTime are replay window of subscription, stop time is set also by duration
+	startTime time.Time
+	stopTime  time.Time
+	match     *regexp.Regexp
+	sinks     *sink.Set
+)
+
 func NewNotificationCommand() *cobra.Command {
 	notificationCmd := &cobra.Command{
 		Use:   "notification",
-		Short: "Execute create-subscription rpc",
+		Short: "Execute create-subscription or establish-subscription rpc",
 		Long: `Execute create-subscription rpc for initiating an event notification subscription that will send asynchronous notifications.
 
+Use --establish for establish-subscription (RFC 8639) of stream, or --datastore for yang-push (RFC 8641) datastore subscription
+with --period or --on-change updates. Subscription status notifications are logged and subscription is deleted on exit.
+Dynamic subscriptions can be modified and deleted only by session, which established them, use --kill for other sessions.
+
+Lost connections, detected by failing keepalives or closed transport, are reconnected with backoff and stream is resubscribed
+with replay from last received event time, when stream advertises replaySupport, or with --establish, when device
+advertises replay feature. Gap of events is logged otherwise.
+
+Replay window is set with --start-time and --stop-time, RFC3339 or relative to now like -2h, start time is used only when
+stream advertises replaySupport, or replay feature with --establish, and ignored with warning otherwise. Server-side filters are set with --filter (subtree) or --xpath, and output can be
+filtered client-side by event name with --event and by content with --match regular expression.
+
+Notifications can be delivered to sinks of --sinks file: rotating files, RFC 5424 syslog, webhook and exec-per-event,
+selected by stream and device group, inventory variable "group", see examples/sinks.yaml. Sinks are buffered and events
+are dropped, when sink is too slow. With sinks, notifications are not written to stdout.
+
 If you want to save notifications to file, use --save flag. Default file name is ip + notifications.xml or provide file name via inventory file
 
 # get all available streams from device
@@ -40,7 +87,23 @@ netconf notification --host 192.168.1.1 --get
 netconf notification --host 192.168.1.1 --stream NETCONF
 
 # subscribe to notification stream with duration, cancel with ctrl+c
-netconf notification --host 192.168.1.1 --stream NETCONF --duration 12m30s`,
+netconf notification --host 192.168.1.1 --stream NETCONF --duration 12m30s
+
+# replay config change events of last two hours, and stop after 30 minutes
+netconf notification --host 192.168.1.1 --start-time -2h --stop-time +30m --event netconf-config-change
+
+# replay events of time window and filter by content
+netconf notification --host 192.168.1.1 --start-time 2024-05-01T10:00:00Z --stop-time 2024-05-01T12:00:00Z --match "eth0"
+
+# yang-push periodic updates of interface statistics every 10 seconds
+netconf notification --host 192.168.1.1 --datastore operational --period 10s \
+  --xpath /if:interfaces/if:interface/if:statistics --ns if=urn:ietf:params:xml:ns:yang:ietf-interfaces
+
+# yang-push on-change updates with dampening
+netconf notification --host 192.168.1.1 --datastore running --on-change --dampening 1s --filter interfaces.xml
+
+# kill subscription 42
+netconf notification --host 192.168.1.1 --delete 42 --kill`,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithCancel(context.Background())
@@ -53,10 +116,17 @@ netconf notification --host 192.168.1.1 --stream NETCONF --duration 12m30s`,
 				cancel()
 			}()
 
-			if opts.duration != 0 {
-				// monitor when subscription ends, as server does not close session
+			if err := output.Validate(opts.output); err != nil {
+				log.Fatal(err)
+			}
+			if err := parseSubscription(); err != nil {
+				log.Fatal(err)
+			}
+
+			if time.Until(stopTime) > 0 {
+				// monitor when subscription ends, in case server does not send completion notification
 				go func() {
-					time.Sleep(opts.duration + 5*time.Second)
+					time.Sleep(time.Until(stopTime) + 5*time.Second)
 					sigs <- syscall.SIGTERM
 				}()
 			}
@@ -65,8 +135,27 @@ netconf notification --host 192.168.1.1 --stream NETCONF --duration 12m30s`,
 			if err != nil {
 				log.Fatalf("Failed to init config, error: %v", err)
 			}
+			if cfg.Aggregate != "" {
+				log.Fatal("Aggregated document is not supported with notifications, use --output-dir instead")
+			}
+
+			if opts.sinks != "" {
+				configs, err := sink.Load(opts.sinks)
+				if err != nil {
+					log.Fatalf("Failed to load sinks, error: %v", err)
+				}
+				if sinks, err = sink.Open(configs); err != nil {
+					log.Fatalf("Failed to open sinks, error: %v", err)
+				}
+			}
 
 			runSubscriptions(cfg)
+
+			if sinks != nil {
+				if err := sinks.Close(); err != nil {
+					log.Error(err)
+				}
+			}
 		},
 	}
 	flags := notificationCmd.Flags()
@@ -74,11 +163,109 @@ netconf notification --host 192.168.1.1 --stream NETCONF --duration 12m30s`,
 	flags.StringVarP(&opts.stream, "stream", "s", "NETCONF", "stream to subscribe")
 	flags.DurationVarP(&opts.duration, "duration", "d", 0, "duration for subscription, eg. 2h30m45s")
 	flags.BoolVar(&opts.persist, "save", false, "save notifications to file, default name is used, if no suffix provided")
+	flags.StringVarP(&opts.output, "output", "o", output.FormatXML, "output format, xml|json|yaml")
+	flags.BoolVar(&opts.establish, "establish", false, "use establish-subscription instead of create-subscription")
+	flags.StringVar(&opts.datastore, "datastore", "", "yang-push datastore subscription, e.g. running|operational")
+	flags.StringVarP(&opts.filters, "filter", "f", "", "subtree filter option, stdin or file containing filter")
+	flags.StringVar(&opts.xpath, "xpath", "", "xpath filter option")
+	flags.StringSliceVar(&opts.ns, "ns", nil, "namespace prefix mapping for xpath filter, prefix=uri")
+	flags.DurationVar(&opts.period, "period", 0, "yang-push periodic update interval")
+	flags.BoolVar(&opts.onChange, "on-change", false, "yang-push on-change updates")
+	flags.DurationVar(&opts.dampening, "dampening", 0, "yang-push on-change dampening period")
+	flags.StringVar(&opts.modify, "modify", "", "modify subscription with id, using filter, period and duration flags")
+	flags.StringVar(&opts.delete, "delete", "", "delete subscription with id")
+	flags.BoolVar(&opts.kill, "kill", false, "kill subscription of other session, used with --delete")
+	flags.BoolVar(&opts.reconnect, "reconnect", true, "reconnect and resubscribe with backoff, when connection to device is lost")
+	flags.StringVar(&opts.startTime, "start-time", "", "replay events since time, RFC3339 or relative, e.g. -2h")
+	flags.StringVar(&opts.stopTime, "stop-time", "", "stop subscription at time, RFC3339 or relative, e.g. +30m")
+	flags.StringArrayVar(&opts.events, "event", nil, "output only notifications of event name, repeatable")
+	flags.StringVar(&opts.match, "match", "", "output only notifications matching regular expression")
+	flags.StringVar(&opts.sinks, "sinks", "", "yaml file containing notification sinks, stdout output is disabled")
+	notificationCmd.MarkFlagsMutuallyExclusive("filter", "xpath")
+	notificationCmd.MarkFlagsMutuallyExclusive("period", "on-change")
+	notificationCmd.MarkFlagsMutuallyExclusive("stop-time", "duration")
+	notificationCmd.MarkFlagsMutuallyExclusive("modify", "delete", "get")
 
 	return notificationCmd
 }
 
-const subscriptionGet = `<netconf xmlns="urn:ietf:params:xml:ns:netmod:notification"><streams/></netconf>`
+// parseSubscription builds subscription, replay window and client-side filters from flags.
+func parseSubscription() error {
+	if opts.datastore != "" && opts.period == 0 && !opts.onChange && opts.modify == "" {
+		return fmt.Errorf("datastore subscription requires --period or --on-change")
+	}
+
+	var err error
+	now := time.Now()
+	if startTime, err = utils.ParseTime(opts.startTime, now); err != nil {
+		return fmt.Errorf("failed to parse start time, error: %v", err)
+	}
+	if stopTime, err = utils.ParseTime(opts.stopTime, now); err != nil {
+		return fmt.Errorf("failed to parse stop time, error: %v", err)
+	}
+	if opts.duration != 0 {
+		stopTime = now.Add(opts.duration)
+	}
+	if !startTime.IsZero() && startTime.After(now) {
+		return fmt.Errorf("start time %s is in future", startTime.Format(time.RFC3339))
+	}
+	if !startTime.IsZero() && !stopTime.IsZero() && !stopTime.After(startTime) {
+		return fmt.Errorf("stop time must be after start time")
+	}
+	if !startTime.IsZero() && opts.datastore != "" {
+		return fmt.Errorf("datastore subscriptions are not replayed, --start-time is supported only with streams")
+	}
+	if stopTime.Before(now) && startTime.IsZero() && !stopTime.IsZero() {
+		return fmt.Errorf("stop time %s in past requires --start-time", stopTime.Format(time.RFC3339))
+	}
+	if opts.match != "" {
+		if match, err = regexp.Compile(opts.match); err != nil {
+			return fmt.Errorf("failed to compile --match expression, error: %v", err)
+		}
+	}
+
+	namespaces, err := config.Namespaces(opts.ns)
+	if err != nil {
+		return fmt.Errorf("failed to parse namespaces, error: %v", err)
+	}
+	subscription = rpc.Subscription{
+		Stream:     opts.stream,
+		Datastore:  opts.datastore,
+		XPath:      opts.xpath,
+		Namespaces: namespaces,
+		Period:     opts.period,
+		OnChange:   opts.onChange,
+		Dampening:  opts.dampening,
+	}
+	if opts.filters != "" {
+		if subscription.Filter, err = utils.ReadFiltersFromUser(opts.filters); err != nil {
+			return fmt.Errorf("failed to read filters, error: %v", err)
+		}
+	}
+	return nil
+}
+
+// matches returns true, when notification passes client-side event name and content filters.
+func matches(notification string) bool {
+	if len(opts.events) > 0 && !slices.Contains(opts.events, rpc.EventName(notification)) {
+		return false
+	}
+	return match == nil || match.MatchString(notification)
+}
+
+// streamName returns name of subscribed stream for sinks, datastore name is used for yang-push.
+func streamName() string {
+	if opts.datastore != "" {
+		return opts.datastore
+	}
+	return opts.stream
+}
+
+const (
+	subscriptionGet = `<netconf xmlns="urn:ietf:params:xml:ns:netmod:notification"><streams/></netconf>`
+	// groupVar is inventory variable of device group, used for selecting sinks
+	groupVar = "group"
+)
 
 func runSubscriptions(config *config.Config) {
 	devicesCount := len(config.Devices)
@@ -90,86 +277,95 @@ func runSubscriptions(config *config.Config) {
 
 	for _, device := range config.Devices {
 		d := device
+		// device context is done also, when subscription is completed by stop time
+		ctx, complete := context.WithCancel(d.Ctx)
+		d.Ctx = ctx
+		st := &stream{lastTime: startTime}
+		group := ""
+		if value, found := d.Vars[groupVar]; found {
+			group = fmt.Sprint(value)
+		}
+		// modules are set after hello, before subscription is created
+		var modules map[string]string
+		writer := output.NewWriter(&d, "notification", opts.output, opts.persist).Appending()
 		handler := func(n netconf.Notification) {
+			if !st.received(n.EventTime, n.String()) {
+				d.Log.Debugf("Skipping replayed duplicate notification, timestamp: %s", n.EventTime)
+				return
+			}
 			d.Log.Infof("Received notification, timestamp: %s", n.EventTime)
-			xmlString := utils.FormatXML(n.String())
-			if opts.persist {
-				var fileName string
-				if d.Suffix != "" {
-					fileName = fmt.Sprintf("%s-%s", d.IP, d.Suffix)
-				} else {
-					fileName = fmt.Sprintf("%s-notifications.xml", d.IP)
+			if event, ok := rpc.ParseSubscriptionEvent(n.String()); ok {
+				if event.Kind == rpc.NotificationComplete || event.Kind == rpc.SubscriptionCompleted {
+					complete()
 				}
-				file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-				if err != nil {
-					d.Log.Warnf("Failed to open file for writing")
+				if !logEvent(&d, event) {
 					return
 				}
-				defer file.Close()
-
-				file.WriteString(xmlString)
-			} else {
-				d.Log.Infof("Notification:\n%s", xmlString)
-			}
-		}
-
-		go func() {
-			defer wg.Done()
-			start := time.Now()
-			sshClient, err := client.DialSSH(&d)
-			if err != nil {
-				log.Errorf("failed to dial ssh, ip: %s, error: %v", d.IP, err)
-				return
 			}
-			defer client.CloseDeviceConn(d.IP)
-
-			transport, err := ncssh.NewTransport(sshClient)
-			if err != nil {
-				log.Errorf("failed to create new transport error: %v", err)
+			if !matches(n.String()) {
+				d.Log.Debugf("Notification %s filtered out", rpc.EventName(n.String()))
 				return
 			}
-			defer transport.Close()
-
-			session, err := netconf.Open(transport, netconf.WithNotificationHandler(handler), netconf.WithLogger(d.Log))
+			xmlString, err := output.Format(n.String(), opts.output, modules)
 			if err != nil {
-				log.Errorf("failed to exchange hello messages, ip: %s, error: %v", d.IP, err)
+				d.Log.Warnf("Failed to format notification: %v", err)
 				return
 			}
-			defer session.Close(d.Ctx)
-
-			if opts.getStreams {
-				get, err := session.Get(d.Ctx,
-					netconf.WithSubtreeFilter(subscriptionGet),
-				)
-				if err != nil {
-					log.Errorf("Failed to get available streams: %v", err)
-				}
-				xmlString := utils.FormatXML(get.String())
-				d.Log.Infof("Available streams:\n%s", xmlString)
-				d.Log.Infof("Fetched available notifications streams, took %.3f seconds", time.Since(start).Seconds())
-			} else {
-				if opts.duration != 0 {
-					if err := session.CreateSubscription(d.Ctx,
-						netconf.WithStreamOption(opts.stream),
-						netconf.WithStartTimeOption(start),
-						netconf.WithStopTimeOption(start.Add(opts.duration)),
-					); err != nil {
-						d.Log.Errorf("Failed to create subscription with duration: %s, %v", err, opts.duration)
-						return
-					}
-					d.Log.Infof("Created subscription with duration: %s, took %.3f seconds", opts.duration, time.Since(start).Seconds())
-				} else {
-					if err := session.CreateSubscription(d.Ctx, netconf.WithStreamOption(opts.stream)); err != nil {
-						d.Log.Errorf("Failed to create subscription: %v", err)
-						return
-					}
-					d.Log.Infof("Created subscription, took %.3f seconds", time.Since(start).Seconds())
+			if sinks != nil {
+				sinks.Write(sink.Event{
+					Host:   d.IP,
+					Stream: streamName(),
+					Name:   rpc.EventName(n.String()),
+					Time:   n.EventTime,
+					Format: opts.output,
+					Data:   xmlString,
+				}, group)
+				if !opts.persist {
+					return
 				}
-				<-d.Ctx.Done()
+			}
+			if err := writer.Write(xmlString); err != nil {
+				d.Log.Warnf("Failed to write notification: %v", err)
+			}
+		}
+		run := func(session *netconf.Session, closed <-chan struct{}) error {
+			modules = output.Modules(session.ServerCapabilities())
+			switch {
+			case opts.getStreams:
+				return getStreams(&d, session)
+			case opts.modify != "" || opts.delete != "":
+				return manage(&d, session)
+			case establishing():
+				return establish(&d, session, st, closed)
+			default:
+				return create(&d, session, st, closed)
+			}
+		}
 
-				d.Log.Infof("Subscription %s ended, duration %.3f seconds", opts.stream, time.Since(start).Seconds())
+		go func() {
+			defer wg.Done()
+			defer complete()
+			if err := supervise(&d, client, st, handler, run); err != nil {
+				d.Log.Error(err)
 			}
 		}()
 	}
 	wg.Wait()
 }
+
+func getStreams(device *config.Device, session *netconf.Session) error {
+	start := time.Now()
+	get, err := session.Get(device.Ctx, netconf.WithSubtreeFilter(subscriptionGet))
+	if err != nil {
+		return fmt.Errorf("failed to get available streams, error: %v", err)
+	}
+	streams, err := output.Format(get.String(), opts.output, output.Modules(session.ServerCapabilities()))
+	if err != nil {
+		return fmt.Errorf("failed to format available streams, error: %v", err)
+	}
+	if err := output.NewWriter(device, "streams", opts.output, false).Write(streams); err != nil {
+		return fmt.Errorf("failed to write available streams, error: %v", err)
+	}
+	device.Log.Infof("Fetched available notifications streams, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
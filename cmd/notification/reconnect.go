@@ -0,0 +1,244 @@
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/rpc"
+	"github.com/networkguild/netconf-cli/pkg/ssh"
+	ncssh "github.com/networkguild/netconf/transport/ssh"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+var errConnectionLost = errors.New("connection to device lost")
+
+// stream is subscription state of device, kept over reconnects.
+type stream struct {
+	mu sync.Mutex
+	// started is time of first successful subscription, zero until subscribed
+	started  time.Time
+	lastTime time.Time
+	// seen holds digests of events received at last event time, as replay starts from it
+	seen map[[sha256.Size]byte]bool
+}
+
+// received tracks events of last event time, returns false for duplicate event replayed after resubscription.
+// Events older than last event time are out of order and passed as such.
+func (s *stream) received(eventTime time.Time, event string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	digest := sha256.Sum256([]byte(event))
+	switch {
+	case eventTime.After(s.lastTime):
+		s.lastTime = eventTime
+		s.seen = map[[sha256.Size]byte]bool{digest: true}
+	case eventTime.Equal(s.lastTime):
+		if s.seen[digest] {
+			return false
+		}
+		if s.seen == nil {
+			s.seen = make(map[[sha256.Size]byte]bool)
+		}
+		s.seen[digest] = true
+	}
+	return true
+}
+
+// subscribed marks subscription created, first subscription sets start time.
+func (s *stream) subscribed(start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started.IsZero() {
+		s.started = start
+		if s.lastTime.IsZero() {
+			s.lastTime = start
+		}
+	}
+}
+
+func (s *stream) active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.started.IsZero()
+}
+
+// resume returns replay start time, which is start time of first subscription or last event time
+// of resubscription. Gap of events is logged, when replay is not supported.
+func (s *stream) resume(device *config.Device, replay bool) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started.IsZero() {
+		if startTime.IsZero() {
+			return time.Time{}
+		}
+		if !replay {
+			device.Log.Warnf("Device does not advertise replay support of stream %s, start time %s is ignored", opts.stream, startTime.Format(time.RFC3339))
+			return time.Time{}
+		}
+		return startTime
+	}
+	if replay {
+		device.Log.Infof("Resubscribing with replay from last event time %s, %.3f seconds since last event",
+			s.lastTime.Format(time.RFC3339), time.Since(s.lastTime).Seconds())
+		return s.lastTime
+	}
+	device.Log.Warnf("Replay is not supported, notifications between %s and %s may be lost",
+		s.lastTime.Format(time.RFC3339), time.Now().Format(time.RFC3339))
+	return time.Time{}
+}
+
+// replayFailed logs window of events lost, when subscribing with replay from start time fails.
+func replayFailed(device *config.Device, from time.Time, err error) {
+	device.Log.Warnf("Failed to subscribe with replay, subscribing without replay, notifications between %s and %s may be lost: %v",
+		from.Format(time.RFC3339), time.Now().Format(time.RFC3339), err)
+}
+
+// streamReplay returns true, when subscribed stream advertises replaySupport in RFC 5277 streams of device.
+func streamReplay(device *config.Device, session *netconf.Session) bool {
+	get, err := session.Get(device.Ctx, netconf.WithSubtreeFilter(subscriptionGet))
+	if err != nil {
+		device.Log.Warnf("Failed to get available streams, replay is not used: %v", err)
+		return false
+	}
+	supported, err := rpc.ReplaySupport(get.String(), opts.stream)
+	if err != nil {
+		device.Log.Warnf("Failed to read replay support of stream %s, replay is not used: %v", opts.stream, err)
+		return false
+	}
+	return supported
+}
+
+// replayFeature returns true, when device advertises replay feature of subscribed notifications, RFC 8639.
+func replayFeature(capabilities []string) bool {
+	for _, capability := range capabilities {
+		if strings.Contains(capability, rpc.SubscribedNotificationsNamespace) && strings.Contains(capability, "replay") {
+			return true
+		}
+	}
+	return false
+}
+
+// supervise runs subscription, which is resubscribed with backoff when connection to device is lost.
+// Reconnecting starts only after first successful subscription, so configuration errors are not retried.
+func supervise(device *config.Device, client *ssh.Client, st *stream, handler func(netconf.Notification),
+	run func(*netconf.Session, <-chan struct{}) error) error {
+	backoff := minBackoff
+	for {
+		connected := time.Now()
+		err := connect(device, client, handler, run)
+		if err == nil || device.Ctx.Err() != nil {
+			return nil
+		}
+		if !opts.reconnect || !st.active() {
+			return err
+		}
+
+		if time.Since(connected) > maxBackoff {
+			backoff = minBackoff
+		}
+		device.Log.Warnf("Subscription interrupted: %v, reconnecting in %s", err, backoff)
+		select {
+		case <-device.Ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// connect opens session to device and runs fn with channel, which is closed when ssh connection is closed.
+func connect(device *config.Device, client *ssh.Client, handler func(netconf.Notification),
+	fn func(*netconf.Session, <-chan struct{}) error) error {
+	sshClient, err := client.DialSSH(device)
+	if err != nil {
+		return fmt.Errorf("failed to dial ssh, error: %v", err)
+	}
+	defer client.CloseDeviceConn(device.IP)
+
+	closed := make(chan struct{})
+	go func() {
+		_ = sshClient.Wait()
+		close(closed)
+	}()
+
+	transport, err := ncssh.NewTransport(sshClient)
+	if err != nil {
+		return fmt.Errorf("failed to create new transport, error: %v", err)
+	}
+	defer transport.Close()
+
+	session, err := netconf.Open(transport, netconf.WithNotificationHandler(handler), netconf.WithLogger(device.Log))
+	if err != nil {
+		return fmt.Errorf("failed to exchange hello messages, error: %v", err)
+	}
+	defer func() {
+		// session may be dead already, so closing is not waited for long
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = session.Close(ctx)
+	}()
+	return fn(session, closed)
+}
+
+// create creates subscription with create-subscription rpc and waits until context is done or connection is lost.
+func create(device *config.Device, session *netconf.Session, st *stream, closed <-chan struct{}) error {
+	start := time.Now()
+	s, err := renderSubscription(device)
+	if err != nil {
+		return err
+	}
+	if s.XPath != "" {
+		if err := rpc.CheckXPath(session); err != nil {
+			return err
+		}
+	}
+	// streams are read once per session, only when replay may be needed
+	var replay bool
+	if !startTime.IsZero() || st.active() {
+		replay = streamReplay(device, session)
+	}
+	replayFrom := st.resume(device, replay)
+	s.StartTime = replayFrom
+	if !stopTime.IsZero() {
+		// stop time requires start time
+		if s.StartTime.IsZero() {
+			s.StartTime = start
+		}
+		s.StopTime = stopTime
+	}
+
+	_, err = session.Dispatch(device.Ctx, s.Create())
+	if err != nil && !replayFrom.IsZero() && st.active() {
+		replayFailed(device, replayFrom, err)
+		s.StartTime, s.StopTime = time.Time{}, time.Time{}
+		_, err = session.Dispatch(device.Ctx, s.Create())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create subscription, error: %v", err)
+	}
+	st.subscribed(start)
+	if !s.StopTime.IsZero() {
+		device.Log.Infof("Created subscription until %s, took %.3f seconds", s.StopTime.Format(time.RFC3339), time.Since(start).Seconds())
+	} else {
+		device.Log.Infof("Created subscription, took %.3f seconds", time.Since(start).Seconds())
+	}
+
+	select {
+	case <-device.Ctx.Done():
+	case <-closed:
+		return errConnectionLost
+	}
+	device.Log.Infof("Subscription %s ended, duration %.3f seconds", opts.stream, time.Since(st.started).Seconds())
+	return nil
+}
@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func TestStreamReceived(t *testing.T) {
+	type event struct {
+		time     time.Time
+		data     string
+		expected bool
+	}
+	tests := []struct {
+		name   string
+		events []event
+		last   time.Time
+	}{
+		{
+			name: "duplicates at last event time",
+			events: []event{
+				{time: t0, data: "a", expected: true},
+				{time: t0, data: "b", expected: true},
+				// replayed after resubscription from last event time
+				{time: t0, data: "a", expected: false},
+				{time: t0, data: "b", expected: false},
+				{time: t0, data: "c", expected: true},
+			},
+			last: t0,
+		},
+		{
+			name: "later event resets seen events",
+			events: []event{
+				{time: t0, data: "a", expected: true},
+				{time: t0.Add(time.Second), data: "a", expected: true},
+				{time: t0.Add(time.Second), data: "a", expected: false},
+			},
+			last: t0.Add(time.Second),
+		},
+		{
+			name: "out of order events are passed",
+			events: []event{
+				{time: t0.Add(time.Second), data: "a", expected: true},
+				{time: t0, data: "b", expected: true},
+				{time: t0, data: "b", expected: true},
+				{time: t0.Add(time.Second), data: "a", expected: false},
+			},
+			last: t0.Add(time.Second),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st := &stream{}
+			for i, e := range test.events {
+				assert.Equal(t, e.expected, st.received(e.time, e.data), "event %d", i)
+			}
+			assert.Equal(t, test.last, st.lastTime)
+		})
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	device := &config.Device{IP: "192.168.1.1", Log: log.New(io.Discard)}
+	subscribedAt := t0.Add(time.Hour)
+	tests := []struct {
+		name      string
+		startTime time.Time
+		started   bool
+		replay    bool
+		expected  time.Time
+	}{
+		{name: "first subscription without start time", replay: true},
+		{name: "first subscription with start time", startTime: t0, replay: true, expected: t0},
+		{name: "start time without replay support is ignored", startTime: t0},
+		{name: "resubscription replays from last event time", started: true, replay: true, expected: subscribedAt},
+		{name: "resubscription with start time replays from last event time", startTime: t0, started: true, replay: true, expected: subscribedAt},
+		{name: "resubscription without replay support", started: true},
+	}
+	defer func(saved time.Time) { startTime = saved }(startTime)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			startTime = test.startTime
+			st := &stream{lastTime: startTime}
+			if test.started {
+				st.subscribed(subscribedAt)
+				// event received after subscription moves last event time
+				st.received(subscribedAt, "a")
+			}
+			assert.Equal(t, test.expected, st.resume(device, test.replay))
+		})
+	}
+}
+
+func TestStreamSubscribed(t *testing.T) {
+	st := &stream{}
+	assert.False(t, st.active())
+
+	st.subscribed(t0)
+	assert.True(t, st.active())
+	assert.Equal(t, t0, st.started)
+	assert.Equal(t, t0, st.lastTime)
+
+	// resubscription keeps first start time and last event time
+	st.received(t0.Add(time.Second), "a")
+	st.subscribed(t0.Add(time.Minute))
+	assert.Equal(t, t0, st.started)
+	assert.Equal(t, t0.Add(time.Second), st.lastTime)
+
+	// last event time is kept from replay start time
+	st = &stream{lastTime: t0.Add(-time.Hour)}
+	st.subscribed(t0)
+	assert.Equal(t, t0.Add(-time.Hour), st.lastTime)
+}
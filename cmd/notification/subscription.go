@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/rpc"
+)
+
+// subscription is establish-subscription and modify-subscription parameters built from flags.
+var subscription rpc.Subscription
+
+// establishing returns true, when subscription is established with RFC 8639 instead of create-subscription.
+func establishing() bool {
+	return opts.establish || opts.datastore != ""
+}
+
+// establish establishes subscription and waits until context is done or connection is lost,
+// subscription is deleted before session is closed.
+func establish(device *config.Device, session *netconf.Session, st *stream, closed <-chan struct{}) error {
+	start := time.Now()
+	s, err := renderSubscription(device)
+	if err != nil {
+		return err
+	}
+	s.StopTime = stopTime
+	if s.Datastore == "" {
+		s.StartTime = st.resume(device, replayFeature(session.ServerCapabilities()))
+	} else if st.active() {
+		device.Log.Infof("Resubscribing datastore %s, updates since %s are not replayed", s.Datastore, st.lastTime.Format(time.RFC3339))
+	}
+
+	reply, err := session.Dispatch(device.Ctx, s.Establish())
+	if err != nil && !s.StartTime.IsZero() {
+		replayFailed(device, s.StartTime, err)
+		s.StartTime = time.Time{}
+		reply, err = session.Dispatch(device.Ctx, s.Establish())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to establish subscription, error: %v", err)
+	}
+	id, err := rpc.SubscriptionID(reply.String())
+	if err != nil {
+		return err
+	}
+	st.subscribed(start)
+	device.Log.Infof("Established subscription %s, took %.3f seconds", id, time.Since(start).Seconds())
+
+	select {
+	case <-device.Ctx.Done():
+	case <-closed:
+		return errConnectionLost
+	}
+
+	// device context is done, subscription may have been completed already by stop-time
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := session.Dispatch(ctx, rpc.DeleteSubscription(id, false)); err != nil {
+		device.Log.Debugf("Failed to delete subscription %s: %v", id, err)
+	} else {
+		device.Log.Infof("Deleted subscription %s", id)
+	}
+	device.Log.Infof("Subscription %s ended, duration %.3f seconds", id, time.Since(st.started).Seconds())
+	return nil
+}
+
+// manage modifies or deletes existing subscription.
+func manage(device *config.Device, session *netconf.Session) error {
+	if opts.delete != "" {
+		if _, err := session.Dispatch(device.Ctx, rpc.DeleteSubscription(opts.delete, opts.kill)); err != nil {
+			return fmt.Errorf("failed to delete subscription %s, error: %v", opts.delete, err)
+		}
+		device.Log.Infof("Deleted subscription %s", opts.delete)
+		return nil
+	}
+
+	s, err := renderSubscription(device)
+	if err != nil {
+		return err
+	}
+	s.StopTime = stopTime
+	if _, err := session.Dispatch(device.Ctx, s.Modify(opts.modify)); err != nil {
+		return fmt.Errorf("failed to modify subscription %s, error: %v", opts.modify, err)
+	}
+	device.Log.Infof("Modified subscription %s", opts.modify)
+	return nil
+}
+
+func renderSubscription(device *config.Device) (rpc.Subscription, error) {
+	s := subscription
+	if s.Filter != "" {
+		filter, err := render.Render(device, []byte(s.Filter))
+		if err != nil {
+			return s, err
+		}
+		s.Filter = string(filter)
+	}
+	return s, nil
+}
+
+// logEvent logs subscription state changes, returns true for yang-push updates, which are written as data.
+func logEvent(device *config.Device, event rpc.SubscriptionEvent) bool {
+	switch event.Kind {
+	case rpc.PushUpdate, rpc.PushChangeUpdate:
+		device.Log.Debugf("Received %s of subscription %s", event.Kind, event.ID)
+		return true
+	case rpc.SubscriptionTerminated, rpc.SubscriptionSuspended:
+		device.Log.Warnf("Subscription %s %s, reason: %s", event.ID, strings.TrimPrefix(event.Kind, "subscription-"), event.Reason)
+	case rpc.ReplayCompleted:
+		device.Log.Infof("Replay of subscription %s completed", event.ID)
+	case rpc.ReplayComplete:
+		device.Log.Info("Replay completed")
+	case rpc.NotificationComplete:
+		device.Log.Info("Subscription completed")
+	default:
+		device.Log.Infof("Subscription %s %s", event.ID, strings.TrimPrefix(event.Kind, "subscription-"))
+	}
+	return false
+}
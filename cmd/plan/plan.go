@@ -0,0 +1,147 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	file    string
+	source  string
+	persist bool
+	dir     string
+	prune   bool
+}
+
+var desired []byte
+
+func NewPlanCommand() *cobra.Command {
+	planCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Compute edit-config from desired config",
+		Long: `Compute minimal edit-config payload, which changes device config to desired config.
+
+Only subtrees of desired config root elements are compared. Differing nodes get merge, replace or create
+operation attributes, repeated list entries are matched by their key leaves (name, id, index, key or *-name, *-id, *-index, *-key).
+Desired config is rendered as template per device, see --vars and --template.
+
+Desired config is partial by default and config missing from it is kept. With --prune desired config replaces
+whole subtrees of its root elements, and everything missing from it under those roots gets delete operation.
+
+# print plan for device
+netconf plan --host 192.168.1.1 --file desired.xml
+
+# plan full replacement of root elements of desired config, deleting config missing from it
+netconf plan --host 192.168.1.1 --file desired.xml --prune
+
+# save plans to directory as <ip>-plan.xml, apply them later
+netconf plan --inventory hosts.ini --file desired.xml --save --dir plans
+netconf apply plans --inventory hosts.ini`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			d, err := utils.ReadFiltersFromUser(opts.file)
+			if err != nil {
+				log.Fatalf("Failed to read desired config, error: %v", err)
+			}
+			desired = []byte(d)
+
+			if err := parallel.RunParallel(cfg, runPlan); err != nil {
+				log.Fatalf("Failed to execute plan")
+			}
+		},
+	}
+	flags := planCmd.Flags()
+	flags.StringVarP(&opts.file, "file", "f", "", "stdin or file containing desired config")
+	flags.StringVarP(&opts.source, "source", "s", "running", "running|candidate|startup")
+	flags.BoolVar(&opts.persist, "save", false, "save plan to file <ip>-plan.xml")
+	flags.StringVar(&opts.dir, "dir", ".", "directory for saved plans")
+	flags.BoolVar(&opts.prune, "prune", false, "delete config missing from desired config under its root elements")
+
+	return planCmd
+}
+
+func runPlan(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	data, err := render.Render(device, desired)
+	if err != nil {
+		return err
+	}
+	nodes, err := xmltree.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse desired config, error: %v", err)
+	}
+	want := xmltree.Unwrap(nodes, "config")
+
+	start := time.Now()
+	reply, err := session.GetConfig(ctx,
+		netconf.Datastore(opts.source),
+		netconf.WithSubtreeFilter(xmltree.Marshal(xmltree.RootFilter(want))),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get %s config, ip: %s, error: %v", opts.source, device.IP, err)
+	}
+
+	actual, err := xmltree.ParseReply(reply.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse %s config, error: %v", opts.source, err)
+	}
+
+	edits := xmltree.Plan(actual, want, opts.prune)
+	if len(edits) == 0 {
+		device.Result.Status = parallel.StatusUnchanged
+		device.Log.Infof("No changes, %s config is in desired state", opts.source)
+		return nil
+	}
+	device.Result.Status = parallel.StatusChanged
+
+	var changes, deletes int
+	xmltree.Walk(edits, func(node *xmltree.Node) {
+		switch node.Operation() {
+		case "":
+		case xmltree.OperationDelete:
+			changes++
+			deletes++
+		default:
+			changes++
+		}
+	})
+	if deletes > 0 {
+		device.Log.Warnf("Plan deletes %d nodes missing from desired config", deletes)
+	}
+
+	plan := utils.FormatXML(xmltree.Marshal(xmltree.PlanDocument(edits)))
+	if opts.persist {
+		if err := os.MkdirAll(opts.dir, 0755); err != nil {
+			return fmt.Errorf("failed to create plan directory, error: %v", err)
+		}
+		name := filepath.Join(opts.dir, fmt.Sprintf("%s-plan.xml", device.IP))
+		if err := os.WriteFile(name, []byte(plan), 0644); err != nil {
+			return fmt.Errorf("failed to write plan, error: %v", err)
+		}
+		device.Result.SetDetail("plan", name)
+		device.Log.Infof("Saved plan with %d changes to file %s", changes, name)
+	} else {
+		device.Log.Infof("Plan:\n%s", plan)
+	}
+	device.Log.Infof("Executed plan, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
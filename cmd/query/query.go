@@ -0,0 +1,74 @@
+package query
+
+import (
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/query"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	query query.Options
+	ns    []string
+}
+
+func NewQueryCommand() *cobra.Command {
+	queryCmd := &cobra.Command{
+		Use:   "query FILES...",
+		Short: "Query saved replies with xpath",
+		Long: `Evaluate xpath selects against saved replies or configs, without connecting to devices.
+
+Every match of first --select is one row, other selects are evaluated with matched node as context,
+so relative paths select values of same list entry. File path, as given, is used as device column.
+
+# interface names and oper-status of all saved replies
+netconf query *-get-*.xml --select name=//interfaces-state/interface/name --select status=../oper-status
+
+# csv of hostnames
+netconf query backups/*.xml --select hostname=//system/hostname --csv`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			namespaces, err := config.Namespaces(opts.ns)
+			if err != nil {
+				log.Fatalf("Failed to parse namespaces, error: %v", err)
+			}
+			columns, err := query.ParseColumns(opts.query.Selects, namespaces)
+			if err != nil {
+				log.Fatalf("Failed to parse selects, error: %v", err)
+			}
+
+			table := query.NewTable(columns)
+			devices := make([]string, 0, len(args))
+			for _, file := range args {
+				// rows are keyed by path, so same file given twice is queried once
+				if slices.Contains(devices, file) {
+					continue
+				}
+				data, err := os.ReadFile(file)
+				if err != nil {
+					log.Fatalf("Failed to read file %s, error: %v", file, err)
+				}
+				nodes, err := xmltree.ParseReply(string(data))
+				if err != nil {
+					log.Fatalf("Failed to parse file %s, error: %v", file, err)
+				}
+				table.Add(file, nodes)
+				devices = append(devices, file)
+			}
+
+			if err := table.Write(os.Stdout, devices, opts.query.CSV); err != nil {
+				log.Fatalf("Failed to write query results, error: %v", err)
+			}
+		},
+	}
+	flags := queryCmd.Flags()
+	query.AddFlags(flags, &opts.query)
+	flags.StringSliceVar(&opts.ns, "ns", nil, "namespace prefix mapping for selects, prefix=uri")
+	_ = queryCmd.MarkFlagRequired("select")
+
+	return queryCmd
+}
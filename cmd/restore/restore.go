@@ -0,0 +1,217 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/archive"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/lock"
+	"github.com/networkguild/netconf-cli/pkg/output"
+	"github.com/networkguild/netconf-cli/pkg/parallel"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/snapshot"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	file       string
+	fromBackup string
+	repo       string
+	yes        bool
+	lock       lock.Options
+}
+
+var (
+	file []byte
+	repo *archive.Archive
+
+	// configs holds restored config per device, filled during preview
+	configs sync.Map
+)
+
+func NewRestoreCommand() *cobra.Command {
+	restoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore config from file or backup",
+		Long: `Restore device config from local file or backup repository.
+
+Config is pushed with copy-config using inline config, or with edit-config replace when device does not
+support inline copy-config, candidate datastore is committed. Changes are previewed per device and
+confirmed before pushing, use --yes to skip confirmation. Backups taken with --strip are refused, as
+replacing config with stripped backup would delete stripped elements from device.
+
+# restore config from file
+netconf restore --host 192.168.1.1 --file config.xml
+
+# restore previous backup of device
+netconf restore --host 192.168.1.1 --from-backup 192.168.1.1@HEAD~1 --repo backups
+
+# restore own backup of every device, without confirmation
+netconf restore --inventory hosts.ini --from-backup @HEAD --repo backups --yes`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.ParseConfig(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			switch {
+			case opts.file != "":
+				f, err := utils.ReadFiltersFromUser(opts.file)
+				if err != nil {
+					log.Fatalf("Failed to read config file, error: %v", err)
+				}
+				file = []byte(f)
+			case opts.fromBackup != "":
+				if opts.repo == "" {
+					log.Fatal("Backup repository --repo must be specified with --from-backup")
+				}
+				repo, err = archive.Open(opts.repo)
+				if err != nil {
+					log.Fatalf("Failed to open backup repository, error: %v", err)
+				}
+			default:
+				log.Fatal("Either --file or --from-backup must be specified")
+			}
+
+			if err := parallel.RunParallel(cfg, runPreview); err != nil {
+				log.Fatalf("Failed to preview restore")
+			}
+
+			var changed []config.Device
+			for _, device := range cfg.Devices {
+				if device.Result.Status == parallel.StatusChanged {
+					changed = append(changed, device)
+				}
+			}
+			if len(changed) == 0 {
+				log.Info("All devices already have restored config, nothing to do")
+				return
+			}
+
+			if !opts.yes {
+				ok, err := utils.Confirm(fmt.Sprintf("Restore config to %d devices?", len(changed)))
+				if err != nil {
+					log.Fatalf("Failed to confirm restore, use --yes to skip confirmation, error: %v", err)
+				}
+				if !ok {
+					log.Warn("Restore cancelled")
+					return
+				}
+			}
+
+			cfg.Devices = changed
+			if err := parallel.RunParallel(cfg, runRestore); err != nil {
+				log.Fatalf("Failed to execute restore")
+			}
+		},
+	}
+	flags := restoreCmd.Flags()
+	flags.StringVarP(&opts.file, "file", "f", "", "stdin or file containing config to restore")
+	flags.StringVar(&opts.fromBackup, "from-backup", "", "restore backup DEVICE@REV, DEVICE defaults to each device and REV to HEAD")
+	flags.StringVar(&opts.repo, "repo", "", "git repository directory for backups")
+	flags.BoolVarP(&opts.yes, "yes", "y", false, "restore without confirmation")
+	lock.AddFlags(flags, &opts.lock)
+	restoreCmd.MarkFlagsMutuallyExclusive("file", "from-backup")
+
+	return restoreCmd
+}
+
+// restoreConfig reads config restored to device.
+func restoreConfig(device *config.Device) ([]byte, error) {
+	if repo == nil {
+		return render.Render(device, file)
+	}
+
+	ip, rev := archive.ParseRef(opts.fromBackup)
+	if ip == "" {
+		ip = device.IP
+	}
+	stripped, err := repo.Stripped(ip, rev)
+	if err != nil {
+		return nil, err
+	}
+	if len(stripped) > 0 {
+		return nil, fmt.Errorf("backup %s@%s was taken with --strip %s, restoring it would delete stripped elements", ip, rev, strings.Join(stripped, ","))
+	}
+	return repo.Show(ip, rev)
+}
+
+func runPreview(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	data, err := restoreConfig(device)
+	if err != nil {
+		return fmt.Errorf("failed to read config to restore, error: %v", err)
+	}
+	nodes, err := xmltree.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse config to restore, error: %v", err)
+	}
+	desired := xmltree.Unwrap(nodes, "config")
+
+	reply, err := session.GetConfig(ctx, netconf.Running)
+	if err != nil {
+		return fmt.Errorf("failed to get running config, ip: %s, error: %v", device.IP, err)
+	}
+	actual, err := xmltree.ParseReply(reply.String())
+	if err != nil {
+		return fmt.Errorf("failed to parse running config, error: %v", err)
+	}
+
+	// restore replaces whole config, so nodes missing from restored config are deleted
+	edits := xmltree.Plan(actual, desired, true)
+	if len(edits) == 0 {
+		device.Result.Status = parallel.StatusUnchanged
+		device.Log.Info("Running config already matches restored config")
+		return nil
+	}
+
+	configs.Store(device.IP, xmltree.Marshal(desired))
+	device.Result.Status = parallel.StatusChanged
+	device.Log.Infof("Restore changes:\n%s", utils.FormatXML(xmltree.Marshal(xmltree.PlanDocument(edits))))
+	return nil
+}
+
+func runRestore(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	data, found := configs.Load(device.IP)
+	if !found {
+		return fmt.Errorf("no previewed config for device %s", device.IP)
+	}
+
+	datastore := netconf.Running
+	if slices.Contains(session.ServerCapabilities(), netconf.CandidateCapability) {
+		datastore = netconf.Candidate
+	}
+
+	start := time.Now()
+	unlock, err := lock.Hold(ctx, device, session, datastore, opts.lock)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(ctx); err != nil {
+			device.Log.Warnf("Failed to unlock %s datastore: %v", datastore, err)
+		}
+	}()
+	if err := snapshot.Replace(ctx, device, session, data.(string), true); err != nil {
+		return err
+	}
+	output.CollectOK(device, "restore")
+	device.Result.Status = parallel.StatusChanged
+	device.Log.Infof("Executed restore, took %.3f seconds", time.Since(start).Seconds())
+	return nil
+}
@@ -0,0 +1,207 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/output"
+	"github.com/networkguild/netconf-cli/pkg/render"
+	"github.com/networkguild/netconf-cli/pkg/ssh"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	ncssh "github.com/networkguild/netconf/transport/ssh"
+	"github.com/spf13/cobra"
+)
+
+var opts struct {
+	filters  string
+	source   string
+	interval time.Duration
+	duration time.Duration
+	persist  bool
+}
+
+func NewWatchCommand() *cobra.Command {
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Poll get rpc and print changes",
+		Long: `Poll get or get-config rpc with interval over one session per device and print semantic changes
+between samples with timestamps. First sample is baseline, changes are printed as added (+), removed (-) and changed (~) paths.
+
+# watch interface state every 10 seconds, cancel with ctrl+c
+netconf watch --host 192.168.1.1 --filter interfaces.xml
+
+# watch running config for an hour, every sample is appended to file
+netconf watch --inventory hosts.ini --source running --interval 1m --duration 1h --save`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				sig := <-sigs
+				log.Warnf("Received signal %s, stopping watch...", sig)
+				cancel()
+			}()
+
+			if opts.duration != 0 {
+				ctx, cancel = context.WithTimeout(ctx, opts.duration)
+				defer cancel()
+			}
+			if opts.interval <= 0 {
+				log.Fatalf("Invalid interval %s, must be positive", opts.interval)
+			}
+
+			cfg, err := config.ParseConfig(ctx)
+			if err != nil {
+				log.Fatalf("Failed to init config, error: %v", err)
+			}
+
+			if opts.filters != "" {
+				opts.filters, err = utils.ReadFiltersFromUser(opts.filters)
+				if err != nil {
+					log.Fatalf("Failed to read filters, error: %v", err)
+				}
+			}
+
+			runWatch(cfg)
+		},
+	}
+	flags := watchCmd.Flags()
+	flags.StringVarP(&opts.filters, "filter", "f", "", "filter option, stdin or file containing filters")
+	flags.StringVarP(&opts.source, "source", "s", "", "poll get-config of running|candidate|startup instead of get")
+	flags.DurationVarP(&opts.interval, "interval", "n", 10*time.Second, "interval between polls")
+	flags.DurationVarP(&opts.duration, "duration", "d", 0, "duration for watch, eg. 2h30m45s")
+	flags.BoolVar(&opts.persist, "save", false, "append every sample to file, default name is used, if no suffix provided")
+
+	return watchCmd
+}
+
+func runWatch(config *config.Config) {
+	devicesCount := len(config.Devices)
+	var wg sync.WaitGroup
+	wg.Add(devicesCount)
+
+	client := ssh.NewClient(devicesCount, config.Multiplexing, true)
+	defer client.Close()
+
+	for _, device := range config.Devices {
+		d := device
+		go func() {
+			defer wg.Done()
+			sshClient, err := client.DialSSH(&d)
+			if err != nil {
+				log.Errorf("failed to dial ssh, ip: %s, error: %v", d.IP, err)
+				return
+			}
+			defer client.CloseDeviceConn(d.IP)
+
+			transport, err := ncssh.NewTransport(sshClient)
+			if err != nil {
+				log.Errorf("failed to create new transport error: %v", err)
+				return
+			}
+			defer transport.Close()
+
+			session, err := netconf.Open(transport, netconf.WithLogger(d.Log))
+			if err != nil {
+				log.Errorf("failed to exchange hello messages, ip: %s, error: %v", d.IP, err)
+				return
+			}
+			defer session.Close(context.Background())
+
+			if err := watch(&d, session); err != nil {
+				d.Log.Errorf("Watch failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// watch polls device until context is done, printing changes between consecutive samples.
+func watch(device *config.Device, session *netconf.Session) error {
+	filter, err := render.Render(device, []byte(opts.filters))
+	if err != nil {
+		return err
+	}
+
+	var (
+		writer   *output.Writer
+		previous []*xmltree.Node
+		samples  int
+		start    = time.Now()
+		ticker   = time.NewTicker(opts.interval)
+	)
+	defer ticker.Stop()
+	if opts.persist {
+		writer = output.NewWriter(device, "watch", output.FormatXML, true).Appending()
+	}
+	defer func() {
+		device.Log.Infof("Watch ended after %d samples, duration %.3f seconds", samples, time.Since(start).Seconds())
+	}()
+
+	for {
+		sampled := time.Now()
+		reply, err := poll(device.Ctx, session, string(filter))
+		if err != nil {
+			if device.Ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		nodes, err := xmltree.ParseReply(reply.String())
+		if err != nil {
+			return fmt.Errorf("failed to parse reply, error: %v", err)
+		}
+		samples++
+
+		if writer != nil {
+			sample := fmt.Sprintf("<!-- sample %d, %s -->\n%s", samples, sampled.Format(time.RFC3339), utils.FormatXML(reply.String()))
+			if err := writer.Write(sample); err != nil {
+				device.Log.Warnf("Failed to save sample: %v", err)
+			}
+		}
+
+		if samples == 1 {
+			device.Log.Infof("Baseline sample received, polling every %s", opts.interval)
+		} else if changes := xmltree.Diff(previous, nodes); len(changes) > 0 {
+			lines := make([]string, 0, len(changes))
+			for _, change := range changes {
+				lines = append(lines, fmt.Sprintf("%s %s %s", sampled.Format(time.RFC3339), device.IP, change))
+			}
+			if err := output.Print(device, "watch", strings.Join(lines, "\n")); err != nil {
+				device.Log.Warnf("Failed to print changes: %v", err)
+			}
+		} else {
+			device.Log.Debugf("No changes in sample %d", samples)
+		}
+		previous = nodes
+
+		select {
+		case <-device.Ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func poll(ctx context.Context, session *netconf.Session, filter string) (*netconf.Reply, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.interval+time.Minute)
+	defer cancel()
+
+	if opts.source != "" {
+		return session.GetConfig(ctx, netconf.Datastore(opts.source), netconf.WithSubtreeFilter(filter))
+	}
+	return session.Get(ctx, netconf.WithSubtreeFilter(filter))
+}
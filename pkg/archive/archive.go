@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// strippedTrailer records names stripped from backups in commit message.
+const strippedTrailer = "Stripped: "
+
+// Archive is local git repository, containing one config file per device.
+type Archive struct {
+	Dir string
+}
+
+// Open opens archive in dir, git repository is initialized if missing.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory, %v", err)
+	}
+	a := &Archive{Dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err := a.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// File returns archive file name of device.
+func File(ip string) string {
+	return ip + ".xml"
+}
+
+// Write stores device config, reporting whether it differs from stored config.
+func (a *Archive) Write(ip string, data []byte) (bool, error) {
+	path := filepath.Join(a.Dir, File(ip))
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s, %v", path, err)
+	}
+	return true, nil
+}
+
+// Commit commits all changed device files, returns false if nothing was changed.
+func (a *Archive) Commit(message string) (bool, error) {
+	if _, err := a.git("add", "--all"); err != nil {
+		return false, err
+	}
+	status, err := a.git("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(status)) == 0 {
+		return false, nil
+	}
+
+	// archive repository gets its own identity, when user has not configured one
+	if _, err := a.git("config", "user.email"); err != nil {
+		if _, err := a.git("config", "user.name", "netconf"); err != nil {
+			return false, err
+		}
+		if _, err := a.git("config", "user.email", "netconf@localhost"); err != nil {
+			return false, err
+		}
+	}
+	if _, err := a.git("commit", "--quiet", "--message", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Log returns commit history of device file.
+func (a *Archive) Log(ip string) (string, error) {
+	out, err := a.git("log", "--format=%h %ad %s", "--date=iso", "--", File(ip))
+	return string(out), err
+}
+
+// Show returns device config at revision, e.g. HEAD, HEAD~2 or commit hash.
+func (a *Archive) Show(ip, rev string) ([]byte, error) {
+	return a.git("show", fmt.Sprintf("%s:%s", rev, File(ip)))
+}
+
+// Diff returns unified diff of device config between revisions.
+func (a *Archive) Diff(ip, from, to string) (string, error) {
+	out, err := a.git("diff", from, to, "--", File(ip))
+	return string(out), err
+}
+
+// StripTrailer returns commit message trailer recording names stripped from backups.
+func StripTrailer(names []string) string {
+	return strippedTrailer + strings.Join(names, ",")
+}
+
+// Stripped returns names stripped from device config at revision, read from commit which last changed it.
+func (a *Archive) Stripped(ip, rev string) ([]string, error) {
+	out, err := a.git("log", "-1", "--format=%B", rev, "--", File(ip))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if names, found := strings.CutPrefix(strings.TrimSpace(line), strippedTrailer); found && names != "" {
+			return strings.Split(names, ","), nil
+		}
+	}
+	return nil, nil
+}
+
+// ParseRef splits DEVICE@REV reference, revision defaults to HEAD.
+func ParseRef(ref string) (string, string) {
+	device, rev, found := strings.Cut(ref, "@")
+	if !found || rev == "" {
+		rev = "HEAD"
+	}
+	return device, rev
+}
+
+func (a *Archive) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = a.Dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed, %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package check
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Plugin exit codes of nagios plugin api.
+const (
+	OK       = 0
+	Warning  = 1
+	Critical = 2
+	Unknown  = 3
+)
+
+var statusNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// Range is nagios threshold range, value outside range alerts, or inside range with @ prefix.
+// Formats are 10 (0..10), 10: (10..inf), ~:10 (-inf..10), 10:20 and @10:20.
+type Range struct {
+	text   string
+	start  float64
+	end    float64
+	inside bool
+}
+
+// ParseRange parses threshold range, empty range returns nil.
+func ParseRange(s string) (*Range, error) {
+	if s == "" {
+		return nil, nil
+	}
+	r := &Range{text: s, end: math.Inf(1)}
+	if strings.HasPrefix(s, "@") {
+		r.inside = true
+		s = s[1:]
+	}
+
+	start, end, found := strings.Cut(s, ":")
+	if !found {
+		start, end = "0", s
+	}
+	var err error
+	switch start {
+	case "~":
+		r.start = math.Inf(-1)
+	case "":
+		r.start = 0
+	default:
+		if r.start, err = strconv.ParseFloat(start, 64); err != nil {
+			return nil, fmt.Errorf("invalid range %s, %v", r.text, err)
+		}
+	}
+	if end != "" {
+		if r.end, err = strconv.ParseFloat(end, 64); err != nil {
+			return nil, fmt.Errorf("invalid range %s, %v", r.text, err)
+		}
+	}
+	if r.start > r.end {
+		return nil, fmt.Errorf("invalid range %s, start is greater than end", r.text)
+	}
+	return r, nil
+}
+
+// Alert returns true if value alerts with range.
+func (r *Range) Alert(v float64) bool {
+	outside := v < r.start || v > r.end
+	if r.inside {
+		return !outside
+	}
+	return outside
+}
+
+func (r *Range) String() string {
+	if r == nil {
+		return ""
+	}
+	return r.text
+}
+
+// Thresholds of check, numeric ranges are used when value is number and string values otherwise.
+type Thresholds struct {
+	Warning  *Range
+	Critical *Range
+	// Expect is critical when value differs, WarningString and CriticalString when value equals
+	Expect         *string
+	WarningString  *string
+	CriticalString *string
+}
+
+// Evaluate returns status of value.
+func (t Thresholds) Evaluate(value string) int {
+	if t.Expect != nil && value != *t.Expect {
+		return Critical
+	}
+	if t.CriticalString != nil && value == *t.CriticalString {
+		return Critical
+	}
+	if t.WarningString != nil && value == *t.WarningString {
+		return Warning
+	}
+
+	if t.Warning == nil && t.Critical == nil {
+		return OK
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Unknown
+	}
+	if t.Critical != nil && t.Critical.Alert(number) {
+		return Critical
+	}
+	if t.Warning != nil && t.Warning.Alert(number) {
+		return Warning
+	}
+	return OK
+}
+
+// Output formats plugin output line, perfdata is added for numeric values.
+func Output(status int, label, value, message string, t Thresholds) string {
+	if message == "" {
+		message = fmt.Sprintf("%s is %s", label, value)
+	}
+	line := fmt.Sprintf("NETCONF %s - %s", statusNames[status], message)
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		line += fmt.Sprintf(" | '%s'=%s;%s;%s;;", strings.ReplaceAll(label, "'", "''"), value, t.Warning, t.Critical)
+	}
+	return line
+}
@@ -0,0 +1,45 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholds(t *testing.T) {
+	up := "up"
+	tests := []struct {
+		warning  string
+		critical string
+		expect   *string
+		value    string
+		status   int
+	}{
+		{warning: "80", critical: "90", value: "50", status: OK},
+		{warning: "80", critical: "90", value: "85", status: Warning},
+		{warning: "80", critical: "90", value: "95", status: Critical},
+		{warning: "80", critical: "90", value: "-1", status: Critical},
+		{warning: "10:", critical: "5:", value: "7", status: Warning},
+		{warning: "~:0", value: "1", status: Warning},
+		{critical: "@1:2", value: "1.5", status: Critical},
+		{critical: "@1:2", value: "3", status: OK},
+		{critical: "90", value: "n/a", status: Unknown},
+		{expect: &up, value: "up", status: OK},
+		{expect: &up, value: "down", status: Critical},
+	}
+	for _, test := range tests {
+		warning, err := ParseRange(test.warning)
+		assert.NoError(t, err)
+		critical, err := ParseRange(test.critical)
+		assert.NoError(t, err)
+
+		thresholds := Thresholds{Warning: warning, Critical: critical, Expect: test.expect}
+		assert.Equal(t, test.status, thresholds.Evaluate(test.value), "value %s, warning %s, critical %s", test.value, test.warning, test.critical)
+	}
+
+	warning, _ := ParseRange("80")
+	critical, _ := ParseRange("90")
+	assert.Equal(t, "NETCONF WARNING - cpu is 85 | 'cpu'=85;80;90;;", Output(Warning, "cpu", "85", "", Thresholds{Warning: warning, Critical: critical}))
+	_, err := ParseRange("20:10")
+	assert.Error(t, err)
+}
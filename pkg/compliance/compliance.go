@@ -0,0 +1,149 @@
+package compliance
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules is compliance rules file, rule xpath's are resolved with namespaces mapping of prefix to uri.
+type Rules struct {
+	Namespaces map[string]string `yaml:"namespaces"`
+	Rules      []*Rule           `yaml:"rules"`
+}
+
+// Rule selects nodes with xpath and checks their count or values. Without checks rule passes,
+// when xpath selects any node or evaluates to true.
+type Rule struct {
+	Name   string  `yaml:"name"`
+	XPath  string  `yaml:"xpath"`
+	Count  *int    `yaml:"count"`
+	Min    *int    `yaml:"min"`
+	Max    *int    `yaml:"max"`
+	Equals *string `yaml:"equals"`
+	Match  string  `yaml:"match"`
+
+	xpath *xmltree.XPath
+	match *regexp.Regexp
+}
+
+// Result is outcome of rule on single device.
+type Result struct {
+	Rule    string `json:"rule"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// Load reads and compiles rules file.
+func Load(path string) (*Rules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file, %v", err)
+	}
+
+	var rules Rules
+	if err := yaml.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file, %v", err)
+	}
+	if len(rules.Rules) == 0 {
+		return nil, fmt.Errorf("no rules found from %s", path)
+	}
+
+	for i, rule := range rules.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if rule.xpath, err = xmltree.CompileXPath(rule.XPath, rules.Namespaces); err != nil {
+			return nil, fmt.Errorf("rule %s: %v", rule.Name, err)
+		}
+		if rule.Match != "" {
+			if rule.match, err = regexp.Compile(rule.Match); err != nil {
+				return nil, fmt.Errorf("rule %s: invalid match, %v", rule.Name, err)
+			}
+		}
+	}
+	return &rules, nil
+}
+
+// Evaluate evaluates all rules against config nodes.
+func (r *Rules) Evaluate(nodes []*xmltree.Node) []Result {
+	results := make([]Result, 0, len(r.Rules))
+	for _, rule := range r.Rules {
+		message := rule.evaluate(nodes)
+		results = append(results, Result{Rule: rule.Name, Passed: message == "", Message: message})
+	}
+	return results
+}
+
+// evaluate returns failure message, or empty string when rule passes.
+func (r *Rule) evaluate(nodes []*xmltree.Node) string {
+	v := r.xpath.Evaluate(nodes)
+	selected, ok := v.([]*xmltree.Node)
+	if !ok {
+		value := xmltree.XPathString(v)
+		switch {
+		case r.Equals != nil && value != *r.Equals:
+			return fmt.Sprintf("value %q does not equal %q", value, *r.Equals)
+		case r.match != nil && !r.match.MatchString(value):
+			return fmt.Sprintf("value %q does not match %q", value, r.Match)
+		case r.Equals == nil && r.match == nil && !xmltree.XPathBool(v):
+			return "expression is false"
+		}
+		return ""
+	}
+
+	n := len(selected)
+	switch {
+	case r.Count != nil && n != *r.Count:
+		return fmt.Sprintf("selected %d nodes, expected %d%s", n, *r.Count, paths(selected))
+	case r.Min != nil && n < *r.Min:
+		return fmt.Sprintf("selected %d nodes, expected at least %d", n, *r.Min)
+	case r.Max != nil && n > *r.Max:
+		return fmt.Sprintf("selected %d nodes, expected at most %d%s", n, *r.Max, paths(selected))
+	}
+
+	if r.Equals != nil || r.match != nil || r.Count == nil && r.Min == nil && r.Max == nil {
+		if n == 0 {
+			return "no nodes selected"
+		}
+	}
+	for _, node := range selected {
+		if r.Equals != nil && node.Text != *r.Equals {
+			return fmt.Sprintf("%s value %q does not equal %q", path(node), node.Text, *r.Equals)
+		}
+		if r.match != nil && !r.match.MatchString(node.Text) {
+			return fmt.Sprintf("%s value %q does not match %q", path(node), node.Text, r.Match)
+		}
+	}
+	return ""
+}
+
+// path returns path of node, list entries include their keys.
+func path(node *xmltree.Node) string {
+	var segments []string
+	for n := node; n != nil; n = n.Parent {
+		segments = append([]string{xmltree.Segment(n)}, segments...)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// paths lists up to three paths of unexpected nodes.
+func paths(nodes []*xmltree.Node) string {
+	const limit = 3
+	var list []string
+	for i, node := range nodes {
+		if i == limit {
+			list = append(list, fmt.Sprintf("and %d more", len(nodes)-limit))
+			break
+		}
+		list = append(list, path(node))
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(list, ", ")
+}
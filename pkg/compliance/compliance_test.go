@@ -0,0 +1,120 @@
+package compliance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/stretchr/testify/assert"
+)
+
+const running = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><data>
+<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth0</name><description>uplink</description></interface>
+    <interface><name>eth1</name></interface>
+</interfaces>
+<system>
+    <hostname>pe-1</hostname>
+    <ssh><version>1</version></ssh>
+    <ntp><server>10.0.0.1</server><server>10.0.0.2</server></ntp>
+</system>
+</data></rpc-reply>`
+
+func TestEvaluateExample(t *testing.T) {
+	rules, err := Load("../../examples/compliance.yaml")
+	assert.NoError(t, err)
+	nodes, err := xmltree.ParseReply(running)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Result{
+		{Rule: "every interface has description", Message: "selected 1 nodes, expected 0: /interfaces/interface[name=eth1]"},
+		{Rule: "ssh v1 disabled", Message: `/system/ssh/version value "1" does not equal "2"`},
+		{Rule: "at least two ntp servers", Passed: true},
+		{Rule: "hostname follows naming", Passed: true},
+	}, rules.Evaluate(nodes))
+}
+
+func TestEvaluate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+namespaces:
+  if: urn:ietf:params:xml:ns:yang:ietf-interfaces
+rules:
+  - xpath: count(//if:interface) = 2
+  - name: too many interfaces
+    xpath: count(//if:interface) > 5
+  - name: hostname value
+    xpath: string(/system/hostname)
+    equals: pe-2
+  - name: hostname expression match
+    xpath: string(/system/hostname)
+    match: "^pe-"
+  - name: missing node
+    xpath: /system/domain
+  - name: at most one ntp server
+    xpath: //ntp/server
+    max: 1
+  - name: interface names
+    xpath: //if:interface/if:name
+    match: "^eth[0-9]$"
+`), 0o644))
+	rules, err := Load(path)
+	assert.NoError(t, err)
+	nodes, err := xmltree.ParseReply(running)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Result{
+		{Rule: "rule-1", Passed: true},
+		{Rule: "too many interfaces", Message: "expression is false"},
+		{Rule: "hostname value", Message: `value "pe-1" does not equal "pe-2"`},
+		{Rule: "hostname expression match", Passed: true},
+		{Rule: "missing node", Message: "no nodes selected"},
+		{Rule: "at most one ntp server", Message: "selected 2 nodes, expected at most 1: /system/ntp/server, /system/ntp/server"},
+		{Rule: "interface names", Passed: true},
+	}, rules.Evaluate(nodes))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"no rules":      "rules: []\n",
+		"invalid xpath": "rules:\n  - xpath: //a[\n",
+		"invalid match": "rules:\n  - xpath: //a\n    match: \"(\"\n",
+	} {
+		path := filepath.Join(t.TempDir(), "rules.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := Load(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	devices := []Device{
+		{IP: "10.0.0.1", Results: []Result{
+			{Rule: "ssh v1 disabled", Passed: true},
+			{Rule: "hostname follows naming", Message: `value "r1" does not match "^pe-"`},
+		}},
+		{IP: "10.0.0.2", Error: "failed to get running config"},
+	}
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	assert.NoError(t, WriteJUnit(path, devices))
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="10.0.0.1" tests="2" failures="1" errors="0">
+    <testcase name="ssh v1 disabled" classname="10.0.0.1"></testcase>
+    <testcase name="hostname follows naming" classname="10.0.0.1">
+      <failure message="value &#34;r1&#34; does not match &#34;^pe-&#34;"></failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="10.0.0.2" tests="1" failures="0" errors="1">
+    <testcase name="evaluate" classname="10.0.0.2">
+      <error message="failed to get running config"></error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, string(b))
+	assert.Equal(t, 1, devices[0].Failed())
+}
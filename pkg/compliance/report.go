@@ -0,0 +1,121 @@
+package compliance
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// Device holds rule results of single device, Error is set when device could not be evaluated.
+type Device struct {
+	IP      string   `json:"ip"`
+	Error   string   `json:"error,omitempty"`
+	Results []Result `json:"results"`
+}
+
+// Failed returns count of failed rules.
+func (d Device) Failed() int {
+	var failed int
+	for _, result := range d.Results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteJSON writes results of all devices with summary to file.
+func WriteJSON(path string, devices []Device) error {
+	var passed, failed int
+	for _, device := range devices {
+		failed += device.Failed()
+		passed += len(device.Results) - device.Failed()
+	}
+
+	b, err := json.MarshalIndent(struct {
+		Time    time.Time      `json:"time"`
+		Summary map[string]int `json:"summary"`
+		Devices []Device       `json:"devices"`
+	}{
+		Time:    time.Now(),
+		Summary: map[string]int{"passed": passed, "failed": failed},
+		Devices: devices,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json results, %v", err)
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes results as JUnit xml, one test suite per device and one test case per rule.
+func WriteJUnit(path string, devices []Device) error {
+	var suites junitSuites
+	for _, device := range devices {
+		suite := junitSuite{Name: device.IP, Tests: len(device.Results), Failures: device.Failed()}
+		if device.Error != "" {
+			suite.Tests, suite.Errors = 1, 1
+			suite.Cases = append(suite.Cases, junitCase{Name: "evaluate", ClassName: device.IP, Error: &junitMessage{Message: device.Error}})
+		}
+		for _, result := range device.Results {
+			c := junitCase{Name: result.Rule, ClassName: device.IP}
+			if !result.Passed {
+				c.Failure = &junitMessage{Message: result.Message}
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	b, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal junit results, %v", err)
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(b, '\n')...), 0644)
+}
+
+// PrintTable prints results of all devices as table.
+func PrintTable(w io.Writer, devices []Device) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DEVICE\tRULE\tRESULT\tMESSAGE")
+	for _, device := range devices {
+		if device.Error != "" {
+			_, _ = fmt.Fprintf(tw, "%s\t-\terror\t%s\n", device.IP, device.Error)
+		}
+		for _, result := range device.Results {
+			status := "pass"
+			if !result.Passed {
+				status = "fail"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", device.IP, result.Rule, status, result.Message)
+		}
+	}
+	return tw.Flush()
+}
@@ -3,15 +3,20 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	Devices      []Device
 	Multiplexing bool
+	Report       string
+	// Aggregate is file for merged document of all device replies
+	Aggregate string
 }
 
 type Device struct {
@@ -20,8 +25,43 @@ type Device struct {
 	Password string
 	Port     int
 	Suffix   string
+	Vars     map[string]any
+	// Template enables rendering payloads and filters as templates, set with --template, --vars or inventory variables
+	Template bool
 	Ctx      context.Context
 	Log      *log.Logger
+	Result   *Result
+}
+
+// Result holds the outcome of a device run, shared between copies of the same Device.
+type Result struct {
+	Status   string
+	Error    string
+	Duration time.Duration
+	Details  map[string]string
+	Changes  []xmltree.Change
+	Replies  []Reply
+}
+
+// Reply is formatted reply of command, collected for aggregated document.
+type Reply struct {
+	Command string
+	Format  string
+	Data    string
+	Time    time.Time
+}
+
+// AddReply records reply of command for the device result.
+func (r *Result) AddReply(command, format, data string) {
+	r.Replies = append(r.Replies, Reply{Command: command, Format: format, Data: data, Time: time.Now()})
+}
+
+// SetDetail records additional key value information for the device result.
+func (r *Result) SetDetail(key, value string) {
+	if r.Details == nil {
+		r.Details = make(map[string]string)
+	}
+	r.Details[key] = value
 }
 
 func ParseConfig(ctx context.Context) (*Config, error) {
@@ -30,8 +70,19 @@ func ParseConfig(ctx context.Context) (*Config, error) {
 		username = viper.GetString("username")
 		password = viper.GetString("password")
 		port     = viper.GetInt("port")
+		vars     map[string]map[string]any
+		template = viper.GetBool("template")
 	)
 
+	if path := viper.GetString("vars"); path != "" {
+		v, err := utils.ReadVarsFromUser(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vars file, error: %v", err)
+		}
+		vars = v
+		template = true
+	}
+
 	if ips := viper.GetStringSlice("host"); len(ips) > 0 {
 		for _, ip := range ips {
 			devices = append(devices, Device{
@@ -39,8 +90,11 @@ func ParseConfig(ctx context.Context) (*Config, error) {
 				Username: username,
 				Password: password,
 				Port:     port,
+				Vars:     deviceVars(vars, ip, nil),
+				Template: template,
 				Ctx:      ctx,
 				Log:      log.WithPrefix(ip),
+				Result:   &Result{},
 			})
 		}
 	} else {
@@ -49,6 +103,11 @@ func ParseConfig(ctx context.Context) (*Config, error) {
 			return nil, fmt.Errorf("either --host or --invertory, -i must be specified")
 		}
 
+		for _, host := range hosts {
+			if host.IP != "" && len(host.Vars) > 0 {
+				template = true
+			}
+		}
 		for _, host := range hosts {
 			if host.IP == "" {
 				continue
@@ -64,8 +123,11 @@ func ParseConfig(ctx context.Context) (*Config, error) {
 				Password: password,
 				Port:     p,
 				Suffix:   host.Suffix,
+				Vars:     deviceVars(vars, host.IP, host.Vars),
+				Template: template,
 				Ctx:      ctx,
 				Log:      log.WithPrefix(host.IP),
+				Result:   &Result{},
 			})
 		}
 	}
@@ -73,5 +135,35 @@ func ParseConfig(ctx context.Context) (*Config, error) {
 	return &Config{
 		Devices:      devices,
 		Multiplexing: !viper.GetBool("no-multiplexing"),
+		Report:       viper.GetString("report"),
+		Aggregate:    viper.GetString("aggregate"),
 	}, nil
 }
+
+// Namespaces returns namespace prefix mapping from config file, overridden with prefix=uri mappings.
+func Namespaces(mappings []string) (map[string]string, error) {
+	namespaces := viper.GetStringMapString("namespaces")
+	overrides, err := utils.ParseNamespaces(mappings)
+	if err != nil {
+		return nil, err
+	}
+	for prefix, uri := range overrides {
+		namespaces[prefix] = uri
+	}
+	return namespaces, nil
+}
+
+// deviceVars merges template variables, inventory variables override vars file and vars file host variables override defaults.
+func deviceVars(vars map[string]map[string]any, ip string, inventory map[string]any) map[string]any {
+	merged := make(map[string]any)
+	for key, value := range vars[utils.DefaultVarsKey] {
+		merged[key] = value
+	}
+	for key, value := range vars[ip] {
+		merged[key] = value
+	}
+	for key, value := range inventory {
+		merged[key] = value
+	}
+	return merged
+}
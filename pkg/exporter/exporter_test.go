@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/stretchr/testify/assert"
+)
+
+const reply = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><data>
+<interfaces-state xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth0</name><oper-status>up</oper-status><statistics><in-octets>18446744073709551615</in-octets></statistics></interface>
+    <interface><name>eth1</name><oper-status>down</oper-status></interface>
+</interfaces-state>
+</data></rpc-reply>`
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+namespaces:
+  if: urn:ietf:params:xml:ns:yang:ietf-interfaces
+prefix: netconf_
+metrics:
+  - name: interface_in_octets_total
+    type: counter
+    path: //if:interface
+    value: if:statistics/if:in-octets
+    labels:
+      interface: if:name
+  - name: interface_oper_status
+    help: Operational status of interface
+    path: //if:interface
+    value: if:oper-status
+    values: {up: 1, down: 0}
+    labels:
+      interface: if:name
+  - name: interfaces
+    value: count(//if:interface)
+`), 0o644))
+	mapping, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, mapping.Requests(), 1)
+
+	nodes, err := xmltree.ParseReply(reply)
+	assert.NoError(t, err)
+	device := &Device{IP: "10.0.0.1", Up: true, Samples: make(map[*Metric][]Sample)}
+	for _, metric := range mapping.Metrics {
+		device.Samples[metric] = metric.Collect(nodes)
+	}
+	registry := NewRegistry(mapping)
+	registry.Set(device)
+	registry.Set(&Device{IP: "10.0.0.2"})
+
+	var b strings.Builder
+	assert.NoError(t, registry.Write(&b))
+	assert.Equal(t, `# HELP netconf_up Whether last scrape of device succeeded
+# TYPE netconf_up gauge
+netconf_up{device="10.0.0.1"} 1
+netconf_up{device="10.0.0.2"} 0
+# HELP netconf_scrape_duration_seconds Duration of last scrape of device
+# TYPE netconf_scrape_duration_seconds gauge
+netconf_scrape_duration_seconds{device="10.0.0.1"} 0
+netconf_scrape_duration_seconds{device="10.0.0.2"} 0
+# HELP netconf_scrape_errors_total Failed scrapes of device
+# TYPE netconf_scrape_errors_total counter
+netconf_scrape_errors_total{device="10.0.0.1"} 0
+netconf_scrape_errors_total{device="10.0.0.2"} 1
+# TYPE netconf_interface_in_octets_total counter
+netconf_interface_in_octets_total{device="10.0.0.1",interface="eth0"} 1.8446744073709552e+19
+# HELP netconf_interface_oper_status Operational status of interface
+# TYPE netconf_interface_oper_status gauge
+netconf_interface_oper_status{device="10.0.0.1",interface="eth0"} 1
+netconf_interface_oper_status{device="10.0.0.1",interface="eth1"} 0
+# TYPE netconf_interfaces gauge
+netconf_interfaces{device="10.0.0.1"} 2
+`, b.String())
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"duplicate": `
+metrics:
+  - {name: a, value: "1"}
+  - {name: a, value: "2"}`,
+		"reserved": `
+prefix: netconf_
+metrics:
+  - {name: up, value: "1"}`,
+		"scrape": `
+metrics:
+  - {name: netconf_scrape_count, value: "1"}`,
+		"device label": `
+metrics:
+  - {name: a, path: //interface, value: "1", labels: {device: name}}`,
+		"path": `
+metrics:
+  - {name: a, path: count(//interface), value: "1"}`,
+	}
+	for name, mapping := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mapping.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(mapping), 0o644))
+			_, err := Load(path)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TypeCounter = "counter"
+	TypeGauge   = "gauge"
+)
+
+const (
+	// labelDevice is label added to every sample by registry
+	labelDevice = "device"
+
+	metricUp           = "netconf_up"
+	metricScrapePrefix = "netconf_scrape_"
+)
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Mapping is exporter mapping file, metric xpath's are resolved with namespaces mapping of prefix to uri.
+type Mapping struct {
+	Namespaces map[string]string `yaml:"namespaces"`
+	Prefix     string            `yaml:"prefix"`
+	Metrics    []*Metric         `yaml:"metrics"`
+}
+
+// Metric maps values selected from reply of get, or get-config when source is set, to metric samples.
+// Path selects entries, e.g. list entries, and value and labels are evaluated with entry as context node.
+// Without path, value is evaluated once against reply.
+type Metric struct {
+	Name   string            `yaml:"name"`
+	Help   string            `yaml:"help"`
+	Type   string            `yaml:"type"`
+	Source string            `yaml:"source"`
+	Filter string            `yaml:"filter"`
+	Path   string            `yaml:"path"`
+	Value  string            `yaml:"value"`
+	Labels map[string]string `yaml:"labels"`
+	// Values maps non-numeric values, e.g. enumerations, to numbers
+	Values map[string]float64 `yaml:"values"`
+
+	path   *xmltree.XPath
+	value  *xmltree.XPath
+	labels []label
+}
+
+type label struct {
+	name  string
+	xpath *xmltree.XPath
+}
+
+// Request is distinct get or get-config request, shared by metrics with same source and filter.
+type Request struct {
+	Source string
+	Filter string
+}
+
+// Load reads and compiles mapping file.
+func Load(path string) (*Mapping, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file, %v", err)
+	}
+
+	var mapping Mapping
+	if err := yaml.Unmarshal(b, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping file, %v", err)
+	}
+	if len(mapping.Metrics) == 0 {
+		return nil, fmt.Errorf("no metrics found from %s", path)
+	}
+
+	names := make(map[string]bool, len(mapping.Metrics))
+	for i, metric := range mapping.Metrics {
+		metric.Name = mapping.Prefix + metric.Name
+		if !metricName.MatchString(metric.Name) {
+			return nil, fmt.Errorf("metric %d: invalid name %q", i+1, metric.Name)
+		}
+		// duplicate metric names would be exposed with duplicate TYPE lines, failing whole scrape
+		if metric.Name == metricUp || strings.HasPrefix(metric.Name, metricScrapePrefix) {
+			return nil, fmt.Errorf("metric %s: name is reserved for exporter metrics", metric.Name)
+		}
+		if names[metric.Name] {
+			return nil, fmt.Errorf("metric %s: duplicate metric name", metric.Name)
+		}
+		names[metric.Name] = true
+		switch metric.Type {
+		case "":
+			metric.Type = TypeGauge
+		case TypeCounter, TypeGauge:
+		default:
+			return nil, fmt.Errorf("metric %s: invalid type %s, must be %s or %s", metric.Name, metric.Type, TypeCounter, TypeGauge)
+		}
+		if metric.Value == "" {
+			return nil, fmt.Errorf("metric %s: value xpath is required", metric.Name)
+		}
+
+		if metric.Path != "" {
+			if metric.path, err = xmltree.CompileXPath(metric.Path, mapping.Namespaces); err != nil {
+				return nil, fmt.Errorf("metric %s: %v", metric.Name, err)
+			}
+			if _, err := metric.path.Select(nil); err != nil {
+				return nil, fmt.Errorf("metric %s: path must be location path, %v", metric.Name, err)
+			}
+		}
+		if metric.value, err = xmltree.CompileXPath(metric.Value, mapping.Namespaces); err != nil {
+			return nil, fmt.Errorf("metric %s: %v", metric.Name, err)
+		}
+		labels := make([]string, 0, len(metric.Labels))
+		for name := range metric.Labels {
+			labels = append(labels, name)
+		}
+		sort.Strings(labels)
+		for _, name := range labels {
+			if !metricName.MatchString(name) || strings.Contains(name, ":") {
+				return nil, fmt.Errorf("metric %s: invalid label name %q", metric.Name, name)
+			}
+			if name == labelDevice {
+				return nil, fmt.Errorf("metric %s: label name %q is reserved for device address", metric.Name, name)
+			}
+			xpath, err := xmltree.CompileXPath(metric.Labels[name], mapping.Namespaces)
+			if err != nil {
+				return nil, fmt.Errorf("metric %s, label %s: %v", metric.Name, name, err)
+			}
+			metric.labels = append(metric.labels, label{name: name, xpath: xpath})
+		}
+	}
+	return &mapping, nil
+}
+
+// Requests returns distinct requests of metrics in mapping order.
+func (m *Mapping) Requests() []Request {
+	var requests []Request
+	for _, metric := range m.Metrics {
+		request := metric.Request()
+		found := false
+		for _, r := range requests {
+			if r == request {
+				found = true
+			}
+		}
+		if !found {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+func (m *Metric) Request() Request {
+	return Request{Source: m.Source, Filter: strings.TrimSpace(m.Filter)}
+}
+
+// Sample is single value of metric with labels.
+type Sample struct {
+	Labels [][2]string
+	Value  float64
+}
+
+// Collect evaluates metric against reply nodes. Entries without numeric value are skipped.
+func (m *Metric) Collect(nodes []*xmltree.Node) []Sample {
+	if m.path == nil {
+		if value, ok := m.number(m.value.Evaluate(nodes)); ok {
+			return []Sample{{Value: value}}
+		}
+		return nil
+	}
+
+	entries, err := m.path.Select(nodes)
+	if err != nil {
+		return nil
+	}
+	var samples []Sample
+	for _, entry := range entries {
+		value, ok := m.number(m.value.EvaluateFrom(entry, nodes))
+		if !ok {
+			continue
+		}
+		sample := Sample{Value: value}
+		for _, l := range m.labels {
+			sample.Labels = append(sample.Labels, [2]string{l.name, xmltree.XPathString(l.xpath.EvaluateFrom(entry, nodes))})
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func (m *Metric) number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case []*xmltree.Node:
+		if len(v) == 0 {
+			return 0, false
+		}
+	}
+
+	s := strings.TrimSpace(xmltree.XPathString(v))
+	if value, found := m.Values[s]; found {
+		return value, true
+	}
+	switch s {
+	case "true":
+		return 1, true
+	case "false":
+		return 0, true
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	return value, err == nil
+}
@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Device is latest scrape result of device.
+type Device struct {
+	IP       string
+	Up       bool
+	Duration time.Duration
+	Samples  map[*Metric][]Sample
+}
+
+// Registry holds latest results of devices and scrape error counters, safe for concurrent use.
+type Registry struct {
+	mapping *Mapping
+
+	mu      sync.Mutex
+	devices map[string]*Device
+	errors  map[string]int
+}
+
+func NewRegistry(mapping *Mapping) *Registry {
+	return &Registry{mapping: mapping, devices: make(map[string]*Device), errors: make(map[string]int)}
+}
+
+// Set stores scrape result of device, failed scrape increases error counter.
+func (r *Registry) Set(device *Device) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices[device.IP] = device
+	if _, found := r.errors[device.IP]; !found {
+		r.errors[device.IP] = 0
+	}
+	if !device.Up {
+		r.errors[device.IP]++
+	}
+}
+
+// Write writes metrics in prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ips := make([]string, 0, len(r.devices))
+	for ip := range r.devices {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	var b strings.Builder
+	header(&b, "netconf_up", "Whether last scrape of device succeeded", TypeGauge)
+	for _, ip := range ips {
+		up := 0
+		if r.devices[ip].Up {
+			up = 1
+		}
+		sample(&b, "netconf_up", [][2]string{{"device", ip}}, float64(up))
+	}
+	header(&b, "netconf_scrape_duration_seconds", "Duration of last scrape of device", TypeGauge)
+	for _, ip := range ips {
+		sample(&b, "netconf_scrape_duration_seconds", [][2]string{{"device", ip}}, r.devices[ip].Duration.Seconds())
+	}
+	header(&b, "netconf_scrape_errors_total", "Failed scrapes of device", TypeCounter)
+	for _, ip := range ips {
+		sample(&b, "netconf_scrape_errors_total", [][2]string{{"device", ip}}, float64(r.errors[ip]))
+	}
+
+	for _, metric := range r.mapping.Metrics {
+		header(&b, metric.Name, metric.Help, metric.Type)
+		for _, ip := range ips {
+			for _, s := range r.devices[ip].Samples[metric] {
+				sample(&b, metric.Name, append([][2]string{{"device", ip}}, s.Labels...), s.Value)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func header(b *strings.Builder, name, help, kind string) {
+	if help != "" {
+		b.WriteString(fmt.Sprintf("# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)))
+	}
+	b.WriteString(fmt.Sprintf("# TYPE %s %s\n", name, kind))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func sample(b *strings.Builder, name string, labels [][2]string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteString("{")
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(fmt.Sprintf(`%s="%s"`, l[0], labelEscaper.Replace(l[1])))
+		}
+		b.WriteString("}")
+	}
+	b.WriteString(" ")
+	switch {
+	case math.IsInf(value, 1):
+		b.WriteString("+Inf")
+	case math.IsInf(value, -1):
+		b.WriteString("-Inf")
+	case math.IsNaN(value):
+		b.WriteString("NaN")
+	default:
+		b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	b.WriteString("\n")
+}
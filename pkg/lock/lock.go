@@ -0,0 +1,170 @@
+package lock
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/pflag"
+)
+
+const sessionsFilter = `<netconf-state xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring"><sessions><session><session-id>%s</session-id></session></sessions></netconf-state>`
+
+// Options controls lock retries, when datastore is locked by another session, and partial locking.
+type Options struct {
+	Retries int
+	Wait    time.Duration
+	Break   bool
+
+	Partial     []string
+	PartialAuto bool
+	Namespaces  []string
+}
+
+// AddFlags registers lock options to command flags.
+func AddFlags(flags *pflag.FlagSet, opts *Options) {
+	flags.IntVar(&opts.Retries, "lock-retries", 0, "retry lock this many times, if datastore is locked by another session")
+	flags.DurationVar(&opts.Wait, "lock-wait", 10*time.Second, "wait between lock retries")
+	flags.BoolVar(&opts.Break, "break-lock", false, "kill session holding the lock before retrying")
+}
+
+// AddPartialFlags registers partial lock options to command flags.
+func AddPartialFlags(flags *pflag.FlagSet, opts *Options) {
+	flags.StringSliceVar(&opts.Partial, "partial-lock", nil, "use partial-lock on running datastore for xpath's")
+	flags.BoolVar(&opts.PartialAuto, "partial-lock-auto", false, "use partial-lock on running datastore, xpath's derived from payload top-level nodes")
+	flags.StringSliceVar(&opts.Namespaces, "ns", nil, "namespace prefix mapping for xpath's, prefix=uri")
+}
+
+// Holder is netconf session holding the lock, details are read from ietf-netconf-monitoring.
+type Holder struct {
+	SessionID  string
+	Username   string
+	SourceHost string
+	LoginTime  string
+}
+
+func (h Holder) String() string {
+	if h.Username == "" {
+		return fmt.Sprintf("session %s", h.SessionID)
+	}
+	return fmt.Sprintf("session %s (user: %s, host: %s, login time: %s)", h.SessionID, h.Username, h.SourceHost, h.LoginTime)
+}
+
+// Lock locks datastore, retrying with opts when lock is denied. Lock holder is reported to device log and result.
+func Lock(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, opts Options) error {
+	return retry(ctx, device, session, fmt.Sprintf("%s datastore", datastore), opts, func() error {
+		return session.Lock(ctx, datastore)
+	})
+}
+
+func retry(ctx context.Context, device *config.Device, session *netconf.Session, target string, opts Options, lock func() error) error {
+	retries := opts.Retries
+	if opts.Break && retries == 0 {
+		retries = 1
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := lock()
+		if err == nil {
+			return nil
+		}
+
+		sessionID, denied := lockDenied(err)
+		if !denied {
+			return err
+		}
+
+		holder := lookupHolder(ctx, device, session, sessionID)
+		device.Result.SetDetail("lock-holder", holder.String())
+		device.Log.Warnf("Failed to lock %s, locked by %s", target, holder)
+		if attempt >= retries {
+			return fmt.Errorf("failed to lock %s, locked by %s, error: %v", target, holder, err)
+		}
+
+		// session-id 0 means, that lock is held by non-netconf entity, own session is never killed
+		if opts.Break && sessionID != "" && sessionID != "0" && sessionID != strconv.Itoa(int(session.SessionID())) {
+			if err := killSession(ctx, session, sessionID); err != nil {
+				device.Log.Errorf("Failed to kill session %s: %v", sessionID, err)
+			} else {
+				device.Result.SetDetail("lock-broken", sessionID)
+				device.Log.Warnf("Killed session %s holding the lock", sessionID)
+				continue
+			}
+		}
+
+		device.Log.Infof("Retrying lock in %s, attempt %d/%d", opts.Wait, attempt+1, retries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.Wait):
+		}
+	}
+}
+
+// lockDenied parses lock holder session-id from error-info of lock-denied rpc-error.
+func lockDenied(err error) (string, bool) {
+	var rpcErr netconf.RPCError
+	if !errors.As(err, &rpcErr) {
+		return "", false
+	}
+
+	b, err := xml.Marshal(&rpcErr)
+	if err != nil {
+		return "", false
+	}
+	nodes, err := xmltree.Parse(b)
+	if err != nil {
+		return "", false
+	}
+
+	if tag := xmltree.Find(nodes, "error-tag"); tag == nil || tag.Text != "lock-denied" {
+		return "", false
+	}
+	if sessionID := xmltree.Find(nodes, "session-id"); sessionID != nil {
+		return sessionID.Text, true
+	}
+	return "", true
+}
+
+func lookupHolder(ctx context.Context, device *config.Device, session *netconf.Session, sessionID string) Holder {
+	holder := Holder{SessionID: sessionID}
+	if sessionID == "" || sessionID == "0" {
+		return holder
+	}
+
+	reply, err := session.Get(ctx, netconf.WithSubtreeFilter(fmt.Sprintf(sessionsFilter, sessionID)))
+	if err != nil {
+		device.Log.Debugf("Failed to get lock holder session: %v", err)
+		return holder
+	}
+	nodes, err := xmltree.ParseReply(reply.String())
+	if err != nil {
+		device.Log.Debugf("Failed to parse lock holder session: %v", err)
+		return holder
+	}
+
+	if s := xmltree.Find(nodes, "session"); s != nil {
+		for _, child := range s.Children {
+			switch child.Name.Local {
+			case "username":
+				holder.Username = child.Text
+			case "source-host":
+				holder.SourceHost = child.Text
+			case "login-time":
+				holder.LoginTime = child.Text
+			}
+		}
+	}
+	return holder
+}
+
+func killSession(ctx context.Context, session *netconf.Session, sessionID string) error {
+	_, err := session.Dispatch(ctx, []byte(fmt.Sprintf("<kill-session><session-id>%s</session-id></kill-session>", sessionID)))
+	return err
+}
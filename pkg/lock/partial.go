@@ -0,0 +1,148 @@
+package lock
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+)
+
+const (
+	PartialLockCapability = "urn:ietf:params:netconf:capability:partial-lock:1.0"
+	partialLockNamespace  = "urn:ietf:params:xml:ns:netconf:partial-lock:1.0"
+)
+
+// Unlock releases lock taken by Acquire.
+type Unlock func(ctx context.Context) error
+
+// Acquire locks datastore for payload. Partial lock is used, when requested with options, device advertises
+// :partial-lock capability and target is running datastore, otherwise whole datastore is locked.
+func Acquire(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, payload []byte, opts Options) (Unlock, error) {
+	if len(opts.Partial) == 0 && !opts.PartialAuto {
+		return Hold(ctx, device, session, datastore, opts)
+	}
+	if datastore != netconf.Running || !slices.Contains(session.ServerCapabilities(), PartialLockCapability) {
+		device.Log.Debugf("Partial lock not supported for %s datastore, using full lock", datastore)
+		return Hold(ctx, device, session, datastore, opts)
+	}
+
+	xpaths, request, err := partialLockRequest(payload, opts)
+	if err != nil {
+		device.Log.Debugf("Failed to derive partial lock xpath's, using full lock: %v", err)
+		return Hold(ctx, device, session, datastore, opts)
+	}
+
+	var reply string
+	target := fmt.Sprintf("%s datastore nodes %s", datastore, strings.Join(xpaths, ", "))
+	device.Log.Debugf("Locking %s", target)
+	if err := retry(ctx, device, session, target, opts, func() error {
+		r, err := session.Dispatch(ctx, request)
+		if err != nil {
+			return err
+		}
+		reply = r.String()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	nodes, err := xmltree.ParseReply(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse partial-lock reply, error: %v", err)
+	}
+	lockID := xmltree.Find(nodes, "lock-id")
+	if lockID == nil {
+		return nil, fmt.Errorf("failed to find lock-id from partial-lock reply")
+	}
+	device.Log.Debugf("Acquired partial lock %s", lockID.Text)
+
+	return func(ctx context.Context) error {
+		device.Log.Debugf("Releasing partial lock %s", lockID.Text)
+		_, err := session.Dispatch(ctx, []byte(fmt.Sprintf(`<partial-unlock xmlns="%s"><lock-id>%s</lock-id></partial-unlock>`, partialLockNamespace, lockID.Text)))
+		return err
+	}, nil
+}
+
+// Hold locks whole datastore, e.g. for taking and restoring snapshot and changes between them under same lock.
+func Hold(ctx context.Context, device *config.Device, session *netconf.Session, datastore netconf.Datastore, opts Options) (Unlock, error) {
+	device.Log.Debugf("Locking %s datastore", datastore)
+	if err := Lock(ctx, device, session, datastore, opts); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		device.Log.Debugf("Unlocking %s datastore", datastore)
+		return session.Unlock(ctx, datastore)
+	}, nil
+}
+
+// partialLockRequest builds partial-lock rpc, selecting user xpath's or, when none are given, top-level config nodes of payload.
+func partialLockRequest(payload []byte, opts Options) ([]string, []byte, error) {
+	namespaces, err := utils.ParseNamespaces(opts.Namespaces)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	xpaths := opts.Partial
+	if len(xpaths) == 0 {
+		xpaths, namespaces, err = derive(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var decls strings.Builder
+	for prefix, uri := range namespaces {
+		decls.WriteString(fmt.Sprintf(` xmlns:%s="`, prefix))
+		_ = xml.EscapeText(&decls, []byte(uri))
+		decls.WriteString(`"`)
+	}
+
+	var request strings.Builder
+	request.WriteString(fmt.Sprintf(`<partial-lock xmlns="%s">`, partialLockNamespace))
+	for _, xpath := range xpaths {
+		request.WriteString("<select" + decls.String() + ">")
+		_ = xml.EscapeText(&request, []byte(xpath))
+		request.WriteString("</select>")
+	}
+	request.WriteString("</partial-lock>")
+	return xpaths, []byte(request.String()), nil
+}
+
+// derive builds xpath for every top-level node of config element in payload.
+func derive(payload []byte) ([]string, map[string]string, error) {
+	nodes, err := xmltree.Parse(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := xmltree.Find(nodes, "config")
+	if cfg == nil || len(cfg.Children) == 0 {
+		return nil, nil, fmt.Errorf("no config nodes found from payload")
+	}
+	nodes = cfg.Children
+
+	var (
+		xpaths     []string
+		namespaces = make(map[string]string)
+		prefixes   = make(map[string]string)
+	)
+	for _, node := range nodes {
+		if node.Name.Space == "" {
+			xpaths = append(xpaths, "/"+node.Name.Local)
+			continue
+		}
+		prefix, found := prefixes[node.Name.Space]
+		if !found {
+			prefix = fmt.Sprintf("p%d", len(prefixes))
+			prefixes[node.Name.Space] = prefix
+			namespaces[prefix] = node.Name.Space
+		}
+		xpaths = append(xpaths, fmt.Sprintf("/%s:%s", prefix, node.Name.Local))
+	}
+	return xpaths, namespaces, nil
+}
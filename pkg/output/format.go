@@ -0,0 +1,235 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/networkguild/netconf-cli/pkg/utils"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatXML  = "xml"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+
+	xmlnsPrefix = "xmlns"
+)
+
+// Validate returns error for unknown output format.
+func Validate(format string) error {
+	switch format {
+	case FormatXML, FormatJSON, FormatYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %s, must be %s, %s or %s", format, FormatXML, FormatJSON, FormatYAML)
+}
+
+// Modules maps namespaces to module names, parsed from capabilities advertised in hello,
+// e.g. urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20.
+func Modules(capabilities []string) map[string]string {
+	modules := make(map[string]string)
+	for _, capability := range capabilities {
+		namespace, query, found := strings.Cut(capability, "?")
+		if !found {
+			continue
+		}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			continue
+		}
+		if module := values.Get("module"); module != "" {
+			modules[namespace] = module
+		}
+	}
+	return modules
+}
+
+// Format converts rpc-reply or notification to format. Json and yaml follow RFC 7951 naming:
+// names are qualified with module name, when namespace differs from parent and repeated elements are arrays.
+// Leaf types are unknown without schema, so leaf values are always strings and empty leafs are [null].
+func Format(data, format string, modules map[string]string) (string, error) {
+	if format == "" || format == FormatXML {
+		return utils.FormatXML(data), nil
+	}
+
+	nodes, err := xmltree.Parse([]byte(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse reply, error: %v", err)
+	}
+	c := converter{modules: modules}
+
+	var value *object
+	switch {
+	case len(nodes) == 1 && nodes[0].Name.Local == "rpc-reply":
+		children := nodes[0].Children
+		if len(children) == 1 && children[0].Name.Local == "data" {
+			children = children[0].Children
+		}
+		value = c.object(children, nodes[0].Name.Space)
+	case len(nodes) == 1 && nodes[0].Name.Local == "notification":
+		value = &object{}
+		value.set("ietf-restconf:notification", c.object(nodes[0].Children, nodes[0].Name.Space))
+	default:
+		value = c.object(nodes, "")
+	}
+
+	switch format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(value, "", "  ")
+		return string(b), err
+	case FormatYAML:
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}
+	return "", Validate(format)
+}
+
+type converter struct {
+	modules map[string]string
+}
+
+// module returns module name of namespace, falling back to last segment of namespace.
+func (c converter) module(namespace string) string {
+	if module, found := c.modules[namespace]; found {
+		return module
+	}
+	return namespace[strings.LastIndexAny(namespace, ":/")+1:]
+}
+
+func (c converter) name(name, parent string, local string) string {
+	if name == "" || name == parent {
+		return local
+	}
+	return c.module(name) + ":" + local
+}
+
+func (c converter) object(nodes []*xmltree.Node, space string) *object {
+	var (
+		o     = &object{}
+		names []string
+		count = make(map[string]int)
+	)
+	for _, node := range nodes {
+		name := c.name(node.Name.Space, space, node.Name.Local)
+		if count[name] == 0 {
+			names = append(names, name)
+		}
+		count[name]++
+	}
+
+	for _, name := range names {
+		var values []any
+		for _, node := range nodes {
+			if c.name(node.Name.Space, space, node.Name.Local) != name {
+				continue
+			}
+			values = append(values, c.value(node))
+			if meta := c.metadata(node); meta != nil && node.IsLeaf() && count[name] == 1 {
+				o.set("@"+name, meta)
+			}
+		}
+		if count[name] > 1 {
+			o.set(name, values)
+		} else {
+			o.set(name, values[0])
+		}
+	}
+	return o
+}
+
+func (c converter) value(node *xmltree.Node) any {
+	if !node.IsLeaf() {
+		o := c.object(node.Children, node.Name.Space)
+		if meta := c.metadata(node); meta != nil {
+			o.keys = append([]string{"@"}, o.keys...)
+			o.values["@"] = meta
+		}
+		return o
+	}
+	return scalar(node.Text)
+}
+
+// metadata converts attributes to RFC 7952 annotations, namespace declarations are skipped.
+func (c converter) metadata(node *xmltree.Node) *object {
+	var meta *object
+	for _, attr := range node.Attr {
+		if attr.Name.Space == xmlnsPrefix || attr.Name.Local == xmlnsPrefix {
+			continue
+		}
+		if meta == nil {
+			meta = &object{}
+		}
+		meta.set(c.name(attr.Name.Space, "", attr.Name.Local), attr.Value)
+	}
+	return meta
+}
+
+// scalar returns leaf value as string, so that type of leaf does not depend on its value.
+func scalar(text string) any {
+	if text == "" {
+		// empty leaf, RFC 7951 section 6.9
+		return []any{nil}
+	}
+	return text
+}
+
+// object is json object and yaml mapping, keeping order of keys.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *object) set(key string, value any) {
+	if o.values == nil {
+		o.values = make(map[string]any)
+	}
+	if _, found := o.values[key]; !found {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *object) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range o.keys {
+		var value yaml.Node
+		if err := value.Encode(o.values[key]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &value)
+	}
+	return node, nil
+}
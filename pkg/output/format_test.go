@@ -0,0 +1,77 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const reply = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><data>
+<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth0</name><enabled>true</enabled><mtu>1500</mtu><in-octets>18446744073709551615</in-octets></interface>
+    <interface><name>eth1</name><enabled>false</enabled><description>001</description></interface>
+</interfaces>
+<system xmlns="urn:example:system"><hostname>pe-1</hostname><ntp/></system>
+</data></rpc-reply>`
+
+func TestFormat(t *testing.T) {
+	modules := Modules([]string{
+		"urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20",
+		"urn:ietf:params:netconf:base:1.1",
+	})
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: FormatJSON,
+			expected: `{
+  "ietf-interfaces:interfaces": {
+    "interface": [
+      {
+        "name": "eth0",
+        "enabled": "true",
+        "mtu": "1500",
+        "in-octets": "18446744073709551615"
+      },
+      {
+        "name": "eth1",
+        "enabled": "false",
+        "description": "001"
+      }
+    ]
+  },
+  "system:system": {
+    "hostname": "pe-1",
+    "ntp": [
+      null
+    ]
+  }
+}`,
+		},
+		{
+			format: FormatYAML,
+			expected: `ietf-interfaces:interfaces:
+  interface:
+    - name: eth0
+      enabled: "true"
+      mtu: "1500"
+      in-octets: "18446744073709551615"
+    - name: eth1
+      enabled: "false"
+      description: "001"
+system:system:
+  hostname: pe-1
+  ntp:
+    - null`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			out, err := Format(reply, test.format, modules)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+)
+
+// DefaultTemplate is file name template used with --save and with --output-dir, which is plain directory.
+// Device suffix from inventory overrides generated name.
+const DefaultTemplate = "{{.Host}}-{{if .Suffix}}{{.Suffix}}{{else}}{{.Command}}-{{.Date}}.{{.Format}}{{end}}"
+
+var (
+	// stdout is shared by all devices, writes are serialized with mutex
+	stdout   io.Writer = os.Stdout
+	stdoutMu sync.Mutex
+
+	settings struct {
+		dir       *template.Template
+		ndjson    bool
+		aggregate bool
+	}
+)
+
+// Setup sets global output options, dir is file name template or directory and enables saving replies to files,
+// ndjson wraps replies written to stdout to json lines and aggregate collects replies to device results
+// instead of stdout, for merged document written by parallel runner.
+func Setup(dir string, ndjson, aggregate bool) error {
+	settings.ndjson = ndjson
+	settings.aggregate = aggregate
+	if dir == "" {
+		settings.dir = nil
+		return nil
+	}
+	if !strings.Contains(dir, "{{") {
+		dir = filepath.Join(dir, DefaultTemplate)
+	}
+	tmpl, err := template.New("output-dir").Option("missingkey=error").Parse(dir)
+	if err != nil {
+		return fmt.Errorf("failed to parse output-dir template, error: %v", err)
+	}
+	settings.dir = tmpl
+	return nil
+}
+
+// Name is data available in file name template.
+type Name struct {
+	Host    string
+	Command string
+	Suffix  string
+	Format  string
+	// Date is yyyy_mm_dd and Time is hhmmss of start of command
+	Date string
+	Time string
+}
+
+// Writer writes replies of device to stdout, or to files, when saving is enabled with --save or --output-dir.
+type Writer struct {
+	device *config.Device
+	name   Name
+	save   bool
+	append bool
+
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+// NewWriter creates writer for command replies of device, save writes replies to files with default template.
+func NewWriter(device *config.Device, command, format string, save bool) *Writer {
+	if format == "" {
+		format = FormatXML
+	}
+	now := time.Now()
+	return &Writer{
+		device: device,
+		name: Name{
+			Host:    device.IP,
+			Command: command,
+			Suffix:  device.Suffix,
+			Format:  format,
+			Date:    utils.TimeStamp(),
+			Time:    now.Format("150405"),
+		},
+		save:    save || settings.dir != nil,
+		created: make(map[string]bool),
+	}
+}
+
+// Appending makes writer append also first write to existing file, e.g. for notifications.
+func (w *Writer) Appending() *Writer {
+	w.append = true
+	return w
+}
+
+// Write writes formatted data. First write truncates file and later writes of same writer append to it.
+func (w *Writer) Write(data string) error {
+	if !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	if settings.aggregate {
+		collect(w.device, w.name.Command, w.name.Format, data)
+		if !w.save {
+			return nil
+		}
+	}
+	if !w.save {
+		return writeStdout(w.name, data)
+	}
+
+	path, err := w.path()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !w.created[path] {
+		if !w.append {
+			flag = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("failed to create output directory %s, error: %v", dir, err)
+			}
+		}
+	}
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open output file %s, error: %v", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(data); err != nil {
+		return fmt.Errorf("failed to write output file %s, error: %v", path, err)
+	}
+	if !w.created[path] {
+		w.created[path] = true
+		w.device.Log.Infof("Saved %s reply to file %s", w.name.Command, path)
+	}
+	return nil
+}
+
+func (w *Writer) path() (string, error) {
+	tmpl := settings.dir
+	if tmpl == nil {
+		tmpl = template.Must(template.New("default").Parse(DefaultTemplate))
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, w.name); err != nil {
+		return "", fmt.Errorf("failed to render output file name, error: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// Persisted returns true, when replies are saved to files or collected to aggregated document instead of stdout.
+func Persisted(save bool) bool {
+	return save || settings.dir != nil || settings.aggregate
+}
+
+// okReply is recorded for operations, which reply only with ok, e.g. edit-config.
+const okReply = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><ok/></rpc-reply>`
+
+// Collect records xml reply of command to device result for aggregated document, when it is enabled.
+// It is used for replies, which are not written with Writer.
+func Collect(device *config.Device, command, reply string) {
+	if settings.aggregate {
+		collect(device, command, FormatXML, reply)
+	}
+}
+
+// CollectOK records ok reply of command to device result for aggregated document, when it is enabled.
+func CollectOK(device *config.Device, command string) {
+	Collect(device, command, okReply)
+}
+
+func collect(device *config.Device, command, format, data string) {
+	if device.Result != nil {
+		device.Result.AddReply(command, format, data)
+	}
+}
+
+// Print writes text data of device command to stdout, also when saving replies is enabled.
+func Print(device *config.Device, command, data string) error {
+	if !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	return writeStdout(Name{Host: device.IP, Command: command, Suffix: device.Suffix, Format: "text"}, data)
+}
+
+// record is line of ndjson output.
+type record struct {
+	Host      string `json:"host"`
+	Command   string `json:"command"`
+	Timestamp string `json:"timestamp"`
+	Format    string `json:"format"`
+	Data      any    `json:"data"`
+}
+
+func writeStdout(name Name, data string) error {
+	if settings.ndjson {
+		r := record{
+			Host:      name.Host,
+			Command:   name.Command,
+			Timestamp: time.Now().Format(time.RFC3339),
+			Format:    name.Format,
+			Data:      strings.TrimSuffix(data, "\n"),
+		}
+		if name.Format == FormatJSON && json.Valid([]byte(data)) {
+			r.Data = json.RawMessage(data)
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to encode ndjson record, error: %v", err)
+		}
+		data = string(b) + "\n"
+	}
+
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	_, err := io.WriteString(stdout, data)
+	return err
+}
@@ -0,0 +1,36 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+		_ = Setup("", false, false)
+	}()
+	device := &config.Device{IP: "10.0.0.1", Log: log.New(&buf)}
+
+	assert.NoError(t, Setup("", true, false))
+	assert.NoError(t, NewWriter(device, "get", FormatJSON, false).Write("{\n  \"a\": 1\n}"))
+	assert.Regexp(t, `^\{"host":"10.0.0.1","command":"get","timestamp":"[^"]+","format":"json","data":\{"a":1\}\}\n$`, buf.String())
+
+	dir := t.TempDir()
+	assert.NoError(t, Setup(filepath.Join(dir, "{{.Host}}", "{{.Command}}.{{.Format}}"), false, false))
+	writer := NewWriter(device, "notification", FormatXML, false)
+	assert.NoError(t, writer.Write("<a/>"))
+	assert.NoError(t, writer.Write("<b/>"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "10.0.0.1", "notification.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<a/>\n<b/>\n", string(data))
+}
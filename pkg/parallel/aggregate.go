@@ -0,0 +1,120 @@
+package parallel
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+)
+
+// Aggregate is merged json document of device replies, written with --aggregate FILE.json.
+type Aggregate struct {
+	Time    time.Time                  `json:"time"`
+	Devices map[string]AggregateDevice `json:"devices"`
+}
+
+type AggregateDevice struct {
+	Host      string           `json:"host"`
+	Suffix    string           `json:"suffix,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+	Status    string           `json:"status"`
+	Error     string           `json:"error,omitempty"`
+	Replies   []AggregateReply `json:"replies"`
+}
+
+type AggregateReply struct {
+	Command string `json:"command"`
+	Format  string `json:"format"`
+	Data    any    `json:"data"`
+}
+
+// writeAggregate writes replies of all devices to one document, json when file has .json extension, otherwise xml.
+func writeAggregate(config *config.Config) error {
+	now := time.Now()
+	var data string
+	if filepath.Ext(config.Aggregate) == ".json" {
+		aggregate := Aggregate{Time: now, Devices: make(map[string]AggregateDevice, len(config.Devices))}
+		for _, d := range config.Devices {
+			device := AggregateDevice{
+				Host:      d.IP,
+				Suffix:    d.Suffix,
+				Timestamp: timestamp(&d, now),
+				Status:    d.Result.Status,
+				Error:     d.Result.Error,
+				Replies:   make([]AggregateReply, 0, len(d.Result.Replies)),
+			}
+			for _, reply := range d.Result.Replies {
+				r := AggregateReply{Command: reply.Command, Format: reply.Format, Data: strings.TrimSuffix(reply.Data, "\n")}
+				if json.Valid([]byte(reply.Data)) {
+					r.Data = json.RawMessage(reply.Data)
+				}
+				device.Replies = append(device.Replies, r)
+			}
+			aggregate.Devices[d.IP] = device
+		}
+
+		var b strings.Builder
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(aggregate); err != nil {
+			return fmt.Errorf("failed to marshal aggregated document, error: %v", err)
+		}
+		data = strings.TrimSuffix(b.String(), "\n")
+	} else {
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf(`<devices time="%s">`, now.Format(time.RFC3339)))
+		for _, d := range config.Devices {
+			b.WriteString(fmt.Sprintf(`<device host="%s" suffix="%s" timestamp="%s" status="%s"`,
+				escape(d.IP), escape(d.Suffix), timestamp(&d, now).Format(time.RFC3339), escape(d.Result.Status)))
+			if d.Result.Error != "" {
+				b.WriteString(fmt.Sprintf(` error="%s"`, escape(d.Result.Error)))
+			}
+			b.WriteString(">")
+			for _, reply := range d.Result.Replies {
+				b.WriteString(fmt.Sprintf(`<reply command="%s" format="%s">`, escape(reply.Command), escape(reply.Format)))
+				if reply.Format == "xml" {
+					content, err := utils.ExtractData(reply.Data)
+					if err != nil {
+						return fmt.Errorf("failed to parse reply of %s, error: %v", d.IP, err)
+					}
+					b.WriteString(content)
+				} else {
+					b.WriteString(textEscaper.Replace(reply.Data))
+				}
+				b.WriteString("</reply>")
+			}
+			b.WriteString("</device>")
+		}
+		b.WriteString("</devices>")
+		data = utils.FormatXML(b.String())
+	}
+
+	if err := os.WriteFile(config.Aggregate, []byte(data+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write aggregated document, error: %v", err)
+	}
+	return nil
+}
+
+// timestamp returns time of first reply of device, or fallback when device has no replies.
+func timestamp(device *config.Device, fallback time.Time) time.Time {
+	if len(device.Result.Replies) > 0 {
+		return device.Result.Replies[0].Time
+	}
+	return fallback
+}
+
+// textEscaper escapes element content, keeping line breaks of json and yaml replies.
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
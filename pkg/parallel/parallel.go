@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"slices"
+	"time"
 
 	"github.com/alphadose/haxmap"
 	"github.com/charmbracelet/log"
@@ -15,6 +17,14 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+
+	StatusChanged   = "changed"
+	StatusUnchanged = "unchanged"
+)
+
 var errorStore *haxmap.Map[string, error]
 
 func RunParallel(config *config.Config, f func(device *config.Device, session *netconf.Session) error) error {
@@ -30,6 +40,11 @@ func RunParallel(config *config.Config, f func(device *config.Device, session *n
 	for _, device := range config.Devices {
 		d := device
 		wg.Go(func() error {
+			start := time.Now()
+			defer func() {
+				d.Result.Duration = time.Since(start)
+			}()
+
 			sshClient, err := client.DialSSH(&d)
 			if err != nil {
 				errorStore.Set(d.IP, err)
@@ -60,21 +75,112 @@ func RunParallel(config *config.Config, f func(device *config.Device, session *n
 		})
 	}
 
-	defer func() {
-		errorStore.ForEach(func(ip string, err error) bool {
-			var (
-				rpcErr netconf.RPCError
-				msg    = fmt.Sprintf("Device %s failed", ip)
-			)
-			if errors.As(err, &rpcErr) {
-				if xmlErr, err := xml.MarshalIndent(&rpcErr, "", "  "); err == nil {
-					log.Error(msg, "RPCError", string(xmlErr))
-					return true
-				}
+	defer finish(config)
+	return wg.Wait()
+}
+
+// RunLocal runs f for every device in parallel without netconf session, e.g. for evaluating saved backups.
+// Results are collected and reported like with RunParallel.
+func RunLocal(config *config.Config, f func(device *config.Device) error) error {
+	errorStore = haxmap.New[string, error](uintptr(len(config.Devices)))
+
+	var wg errgroup.Group
+	wg.SetLimit(runtime.GOMAXPROCS(0))
+	for _, device := range config.Devices {
+		d := device
+		wg.Go(func() error {
+			start := time.Now()
+			defer func() {
+				d.Result.Duration = time.Since(start)
+			}()
+
+			if err := f(&d); err != nil {
+				errorStore.Set(d.IP, err)
+				return err
 			}
-			log.Error(msg, "error", err)
-			return true
+			return nil
 		})
-	}()
+	}
+
+	defer finish(config)
 	return wg.Wait()
 }
+
+// finish sets device statuses, logs results and summary, writes report and aggregated document and logs device errors.
+func finish(config *config.Config) {
+	summary := make(map[string]int)
+	for _, d := range config.Devices {
+		if err, found := errorStore.Get(d.IP); found {
+			d.Result.Error = err.Error()
+			if d.Result.Status == "" {
+				d.Result.Status = StatusFailed
+			}
+		} else if d.Result.Status == "" {
+			d.Result.Status = StatusOK
+		}
+		summary[d.Result.Status]++
+		if len(d.Result.Details) > 0 || !slices.Contains([]string{StatusOK, StatusFailed}, d.Result.Status) {
+			logResult(&d)
+		}
+	}
+	if len(summary) > 1 || summary[StatusOK]+summary[StatusFailed] == 0 {
+		logSummary(summary)
+	}
+	if config.Report != "" {
+		if err := writeReport(config, summary); err != nil {
+			log.Error("Failed to write report", "error", err)
+		} else {
+			log.Infof("Report saved to %s", config.Report)
+		}
+	}
+	if config.Aggregate != "" {
+		if err := writeAggregate(config); err != nil {
+			log.Error("Failed to write aggregated document", "error", err)
+		} else {
+			log.Infof("Aggregated document saved to %s", config.Aggregate)
+		}
+	}
+
+	errorStore.ForEach(func(ip string, err error) bool {
+		var (
+			rpcErr netconf.RPCError
+			msg    = fmt.Sprintf("Device %s failed", ip)
+		)
+		if errors.As(err, &rpcErr) {
+			if xmlErr, err := xml.MarshalIndent(&rpcErr, "", "  "); err == nil {
+				log.Error(msg, "RPCError", string(xmlErr))
+				return true
+			}
+		}
+		log.Error(msg, "error", err)
+		return true
+	})
+}
+
+func logResult(device *config.Device) {
+	keys := make([]string, 0, len(device.Result.Details))
+	for key := range device.Result.Details {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	keyvals := []any{"status", device.Result.Status}
+	for _, key := range keys {
+		keyvals = append(keyvals, key, device.Result.Details[key])
+	}
+	device.Log.Info("Device result", keyvals...)
+}
+
+func logSummary(summary map[string]int) {
+	statuses := make([]string, 0, len(summary))
+	for status := range summary {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+
+	keyvals := make([]any, 0, 2*len(statuses))
+	for _, status := range statuses {
+		keyvals = append(keyvals, status, summary[status])
+	}
+	log.Info("Run summary", keyvals...)
+}
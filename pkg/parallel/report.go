@@ -0,0 +1,54 @@
+package parallel
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+)
+
+// Report is json document of run results, written with --report.
+type Report struct {
+	Time    time.Time      `json:"time"`
+	Summary map[string]int `json:"summary"`
+	Devices []DeviceReport `json:"devices"`
+}
+
+type DeviceReport struct {
+	IP       string            `json:"ip"`
+	Status   string            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Duration float64           `json:"duration_seconds"`
+	Details  map[string]string `json:"details,omitempty"`
+	Changes  []xmltree.Change  `json:"changes,omitempty"`
+}
+
+func writeReport(config *config.Config, summary map[string]int) error {
+	report := Report{
+		Time:    time.Now(),
+		Summary: summary,
+		Devices: make([]DeviceReport, 0, len(config.Devices)),
+	}
+	for _, d := range config.Devices {
+		report.Devices = append(report.Devices, DeviceReport{
+			IP:       d.IP,
+			Status:   d.Result.Status,
+			Error:    d.Result.Error,
+			Duration: d.Result.Duration.Seconds(),
+			Details:  d.Result.Details,
+			Changes:  d.Result.Changes,
+		})
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report, error: %v", err)
+	}
+	if err := os.WriteFile(config.Report, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report, error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package query
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/log"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/spf13/pflag"
+)
+
+// Options is client-side query options of reply producing commands.
+type Options struct {
+	Selects []string
+	CSV     bool
+}
+
+// AddFlags registers query options to command flags.
+func AddFlags(flags *pflag.FlagSet, opts *Options) {
+	flags.StringArrayVar(&opts.Selects, "select", nil, "xpath evaluated against reply, [name=]XPATH, first select defines rows, repeatable")
+	flags.BoolVar(&opts.CSV, "csv", false, "print selected values as csv instead of table")
+}
+
+// Table returns table collecting selected values, nil when nothing is selected.
+func (o Options) Table(namespaces map[string]string) (*Table, error) {
+	if len(o.Selects) == 0 {
+		return nil, nil
+	}
+	columns, err := ParseColumns(o.Selects, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse selects, error: %v", err)
+	}
+	return NewTable(columns), nil
+}
+
+var columnName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Column is named xpath of query.
+type Column struct {
+	Name  string
+	XPath *xmltree.XPath
+}
+
+// ParseColumns compiles selects, name=XPATH or XPATH, in which case name is last step of path.
+func ParseColumns(selects []string, namespaces map[string]string) ([]Column, error) {
+	columns := make([]Column, 0, len(selects))
+	for _, selection := range selects {
+		name, expr, found := strings.Cut(selection, "=")
+		if !found || !columnName.MatchString(name) {
+			name, expr = defaultName(selection), selection
+		}
+		xpath, err := xmltree.CompileXPath(expr, namespaces)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, Column{Name: name, XPath: xpath})
+	}
+	return columns, nil
+}
+
+// defaultName returns local name of last step of path, e.g. oper-status of /if:interfaces/if:interface[1]/if:oper-status.
+func defaultName(expr string) string {
+	var (
+		name  strings.Builder
+		depth int
+	)
+	for _, r := range expr {
+		switch {
+		case r == '[' || r == '(':
+			depth++
+		case r == ']' || r == ')':
+			depth--
+		case depth == 0 && r == '/':
+			name.Reset()
+		case depth == 0:
+			name.WriteRune(r)
+		}
+	}
+	if _, local, found := strings.Cut(name.String(), ":"); found {
+		return local
+	}
+	if name.Len() == 0 {
+		return expr
+	}
+	return name.String()
+}
+
+// Rows evaluates columns against nodes. Every match of first column is row and other columns are evaluated
+// with matched node as context node, so relative paths like ../oper-status select values of same row.
+// If first column does not select nodes, single row is returned.
+func Rows(columns []Column, nodes []*xmltree.Node) [][]string {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	first := columns[0].XPath.Evaluate(nodes)
+	matches, ok := first.([]*xmltree.Node)
+	if !ok {
+		row := []string{xmltree.XPathString(first)}
+		for _, column := range columns[1:] {
+			row = append(row, xmltree.XPathString(column.XPath.Evaluate(nodes)))
+		}
+		return [][]string{row}
+	}
+
+	rows := make([][]string, 0, len(matches))
+	for _, match := range matches {
+		row := []string{xmltree.XPathString([]*xmltree.Node{match})}
+		for _, column := range columns[1:] {
+			row = append(row, xmltree.XPathString(column.XPath.EvaluateFrom(match, nodes)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Table collects rows of devices, safe for concurrent use.
+type Table struct {
+	columns []Column
+
+	mu   sync.Mutex
+	rows map[string][][]string
+}
+
+func NewTable(columns []Column) *Table {
+	return &Table{columns: columns, rows: make(map[string][][]string)}
+}
+
+// Add evaluates columns against nodes and adds rows of device.
+func (t *Table) Add(device string, nodes []*xmltree.Node) {
+	rows := Rows(t.columns, nodes)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rows[device] = append(t.rows[device], rows...)
+}
+
+// AddReply parses rpc-reply and adds rows of device.
+func (t *Table) AddReply(device, reply string) error {
+	nodes, err := xmltree.ParseReply(reply)
+	if err != nil {
+		return fmt.Errorf("failed to parse reply, error: %v", err)
+	}
+	t.Add(device, nodes)
+	return nil
+}
+
+// Write writes rows of devices in given order as table or csv, first column is device.
+func (t *Table) Write(w io.Writer, devices []string, asCSV bool) error {
+	header := []string{"device"}
+	for _, column := range t.columns {
+		header = append(header, column.Name)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if asCSV {
+		writer := csv.NewWriter(w)
+		_ = writer.Write(header)
+		for _, device := range devices {
+			for _, row := range t.rows[device] {
+				_ = writer.Write(append([]string{device}, row...))
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, device := range devices {
+		for _, row := range t.rows[device] {
+			_, _ = fmt.Fprintln(tw, device+"\t"+strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// Print writes selected values of devices to stdout in inventory order, errors are logged.
+func (t *Table) Print(devices []config.Device, asCSV bool) {
+	ips := make([]string, 0, len(devices))
+	for _, d := range devices {
+		ips = append(ips, d.IP)
+	}
+	if err := t.Write(os.Stdout, ips, asCSV); err != nil {
+		log.Errorf("Failed to write selected values: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+	"github.com/stretchr/testify/assert"
+)
+
+const reply = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><data>
+<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth0</name><oper-status>up</oper-status></interface>
+    <interface><name>eth1</name><oper-status>down</oper-status></interface>
+</interfaces>
+</data></rpc-reply>`
+
+func TestRows(t *testing.T) {
+	nodes, err := xmltree.ParseReply(reply)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		selects []string
+		names   []string
+		rows    [][]string
+	}{
+		{
+			selects: []string{"//if:interface/if:name", "status=../oper-status"},
+			names:   []string{"name", "status"},
+			rows:    [][]string{{"eth0", "up"}, {"eth1", "down"}},
+		},
+		{
+			selects: []string{"//interface[oper-status='down']/name"},
+			names:   []string{"name"},
+			rows:    [][]string{{"eth1"}},
+		},
+		{
+			selects: []string{"total=count(//interface)", "/interfaces/interface[1]/name"},
+			names:   []string{"total", "name"},
+			rows:    [][]string{{"2", "eth0"}},
+		},
+	}
+	for _, test := range tests {
+		columns, err := ParseColumns(test.selects, map[string]string{"if": "urn:ietf:params:xml:ns:yang:ietf-interfaces"})
+		assert.NoError(t, err)
+
+		var names []string
+		for _, column := range columns {
+			names = append(names, column.Name)
+		}
+		assert.Equal(t, test.names, names)
+		assert.Equal(t, test.rows, Rows(columns, nodes))
+	}
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/networkguild/netconf-cli/pkg/config"
+)
+
+// Data is passed to payload templates, e.g. {{.IP}}, {{.Suffix}} or {{.Vars.hostname}}.
+type Data struct {
+	IP     string
+	Port   int
+	Suffix string
+	Vars   map[string]any
+}
+
+// Render executes payload as text/template with device data, when templating is enabled for device,
+// otherwise payload is returned as is. Missing variables are reported as errors, so half rendered payloads are never sent.
+func Render(device *config.Device, payload []byte) ([]byte, error) {
+	if !device.Template {
+		return payload, nil
+	}
+	tmpl, err := template.New(device.IP).Option("missingkey=error").Parse(string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template, error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, Data{
+		IP:     device.IP,
+		Port:   device.Port,
+		Suffix: device.Suffix,
+		Vars:   device.Vars,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render template, error: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderAll renders all payloads for device.
+func RenderAll(device *config.Device, payloads [][]byte) ([][]byte, error) {
+	rendered := make([][]byte, 0, len(payloads))
+	for _, payload := range payloads {
+		r, err := Render(device, payload)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, r)
+	}
+	return rendered, nil
+}
+
+// Print logs rendered payloads for all devices without connecting to them.
+func Print(cfg *config.Config, payloads [][]byte) error {
+	var failed bool
+	for _, device := range cfg.Devices {
+		rendered, err := RenderAll(&device, payloads)
+		if err != nil {
+			device.Log.Errorf("Failed to render payload: %v", err)
+			failed = true
+			continue
+		}
+		for _, r := range rendered {
+			device.Log.Infof("Rendered payload:\n%s", r)
+		}
+	}
+	if failed {
+		return fmt.Errorf("failed to render payloads for all devices")
+	}
+	return nil
+}
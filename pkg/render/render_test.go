@@ -0,0 +1,59 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	device := &config.Device{
+		IP:       "192.168.1.1",
+		Suffix:   "r1.xml",
+		Vars:     map[string]any{"hostname": "r1"},
+		Template: true,
+	}
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "payload without template actions",
+			payload:  "<system><name>r0</name></system>",
+			expected: "<system><name>r0</name></system>",
+		},
+		{
+			name:     "payload with device and vars data",
+			payload:  "<system><name>{{.Vars.hostname}}</name><ip>{{.IP}}</ip></system>",
+			expected: "<system><name>r1</name><ip>192.168.1.1</ip></system>",
+		},
+		{
+			name:    "payload with missing variable",
+			payload: "<system><name>{{.Vars.loopback}}</name></system>",
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered, err := Render(device, []byte(test.payload))
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(rendered))
+		})
+	}
+}
+
+func TestRenderDisabled(t *testing.T) {
+	payload := "<banner>{{ not a template</banner>"
+	rendered, err := Render(&config.Device{IP: "192.168.1.1"}, []byte(payload))
+	assert.NoError(t, err)
+	assert.Equal(t, payload, string(rendered))
+}
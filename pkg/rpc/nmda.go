@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+)
+
+const (
+	NMDANamespace        = "urn:ietf:params:xml:ns:yang:ietf-netconf-nmda"
+	DatastoresNamespace  = "urn:ietf:params:xml:ns:yang:ietf-datastores"
+	OriginNamespace      = "urn:ietf:params:xml:ns:yang:ietf-origin"
+	YangLibraryNamespace = "urn:ietf:params:xml:ns:yang:ietf-yang-library"
+)
+
+// GetData is parameters of get-data rpc, RFC 8526.
+type GetData struct {
+	Datastore string
+	// Filter is subtree filter content, XPath is used instead when set
+	Filter     string
+	XPath      string
+	Namespaces map[string]string
+	// ConfigFilter is true or false, empty returns both config and non-config nodes
+	ConfigFilter string
+	// MaxDepth 0 means unbounded
+	MaxDepth      int
+	WithOrigin    bool
+	OriginFilter  []string
+	NegatedOrigin bool
+	Defaults      string
+}
+
+// Request builds get-data rpc. Origin identities without prefix are resolved from ietf-origin module.
+func (g GetData) Request() []byte {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<get-data xmlns="%s" xmlns:ds="%s" xmlns:or="%s"`, NMDANamespace, DatastoresNamespace, OriginNamespace))
+	for _, prefix := range sortedPrefixes(g.Namespaces) {
+		b.WriteString(fmt.Sprintf(` xmlns:%s="%s"`, prefix, escape(g.Namespaces[prefix])))
+	}
+	b.WriteString(">")
+
+	b.WriteString(fmt.Sprintf("<datastore>ds:%s</datastore>", g.Datastore))
+	switch {
+	case g.XPath != "":
+		b.WriteString(fmt.Sprintf("<xpath-filter>%s</xpath-filter>", escape(g.XPath)))
+	case g.Filter != "":
+		b.WriteString(fmt.Sprintf("<subtree-filter>%s</subtree-filter>", g.Filter))
+	}
+	if g.ConfigFilter != "" {
+		b.WriteString(fmt.Sprintf("<config-filter>%s</config-filter>", g.ConfigFilter))
+	}
+
+	element := "origin-filter"
+	if g.NegatedOrigin {
+		element = "negated-origin-filter"
+	}
+	for _, origin := range g.OriginFilter {
+		if !strings.Contains(origin, ":") {
+			origin = "or:" + origin
+		}
+		b.WriteString(fmt.Sprintf("<%s>%s</%s>", element, origin, element))
+	}
+
+	if g.MaxDepth > 0 {
+		b.WriteString(fmt.Sprintf("<max-depth>%d</max-depth>", g.MaxDepth))
+	}
+	if g.WithOrigin {
+		b.WriteString("<with-origin/>")
+	}
+	if g.Defaults != "" {
+		b.WriteString(fmt.Sprintf("<with-defaults>%s</with-defaults>", g.Defaults))
+	}
+	b.WriteString("</get-data>")
+	return []byte(b.String())
+}
+
+// Datastores discovers datastores supported by device from yang-library, e.g. running, intended and operational.
+func Datastores(ctx context.Context, session *netconf.Session) ([]string, error) {
+	filter := fmt.Sprintf(`<yang-library xmlns="%s"><datastore><name/></datastore></yang-library>`, YangLibraryNamespace)
+	reply, err := session.Get(ctx, netconf.WithSubtreeFilter(filter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get yang-library datastores, error: %v", err)
+	}
+	nodes, err := xmltree.ParseReply(reply.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse yang-library datastores, error: %v", err)
+	}
+
+	var datastores []string
+	xmltree.Walk(nodes, func(node *xmltree.Node) {
+		if node.Name.Local == "name" && node.Parent != nil && node.Parent.Name.Local == "datastore" {
+			// identity value is prefixed, e.g. ds:operational
+			_, name, found := strings.Cut(node.Text, ":")
+			if !found {
+				name = node.Text
+			}
+			datastores = append(datastores, name)
+		}
+	})
+	if len(datastores) == 0 {
+		return nil, fmt.Errorf("no datastores found from yang-library, device does not support nmda")
+	}
+	return datastores, nil
+}
+
+func sortedPrefixes(namespaces map[string]string) []string {
+	prefixes := make([]string, 0, len(namespaces))
+	for prefix := range namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
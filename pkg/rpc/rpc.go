@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/networkguild/netconf"
+)
+
+const (
+	XPathCapability = "urn:ietf:params:netconf:capability:xpath:1.0"
+
+	withDefaultsNamespace = "urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults"
+)
+
+// CheckXPath returns error, if device does not advertise :xpath capability.
+func CheckXPath(session *netconf.Session) error {
+	if !slices.Contains(session.ServerCapabilities(), XPathCapability) {
+		return fmt.Errorf("device does not support xpath filters, :xpath capability not advertised, use subtree filter instead")
+	}
+	return nil
+}
+
+// XPathFilter builds filter element of type xpath, namespaces are declared on filter element.
+func XPathFilter(expr string, namespaces map[string]string) string {
+	var b strings.Builder
+	b.WriteString(`<filter type="xpath"`)
+	for _, prefix := range sortedPrefixes(namespaces) {
+		b.WriteString(fmt.Sprintf(` xmlns:%s="%s"`, prefix, escape(namespaces[prefix])))
+	}
+	b.WriteString(fmt.Sprintf(` select="%s"/>`, escape(expr)))
+	return b.String()
+}
+
+// Get builds get rpc with filter element and optional with-defaults mode.
+func Get(filter, defaults string) []byte {
+	return []byte(fmt.Sprintf("<get>%s%s</get>", filter, withDefaults(defaults)))
+}
+
+// GetConfig builds get-config rpc with filter element and optional with-defaults mode.
+func GetConfig(source, filter, defaults string) []byte {
+	return []byte(fmt.Sprintf("<get-config><source><%s/></source>%s%s</get-config>", source, filter, withDefaults(defaults)))
+}
+
+func withDefaults(mode string) string {
+	if mode == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<with-defaults xmlns="%s">%s</with-defaults>`, withDefaultsNamespace, mode)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
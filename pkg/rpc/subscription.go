@@ -0,0 +1,240 @@
+package rpc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/networkguild/netconf-cli/pkg/xmltree"
+)
+
+const (
+	NotificationNamespace            = "urn:ietf:params:xml:ns:netconf:notification:1.0"
+	SubscribedNotificationsNamespace = "urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"
+	YangPushNamespace                = "urn:ietf:params:xml:ns:yang:ietf-yang-push"
+
+	netmodNotificationNamespace = "urn:ietf:params:xml:ns:netmod:notification"
+)
+
+// Notification kinds of subscribed notifications, yang-push and RFC 5277 replay.
+const (
+	PushUpdate             = "push-update"
+	PushChangeUpdate       = "push-change-update"
+	SubscriptionStarted    = "subscription-started"
+	SubscriptionModified   = "subscription-modified"
+	SubscriptionTerminated = "subscription-terminated"
+	SubscriptionSuspended  = "subscription-suspended"
+	SubscriptionResumed    = "subscription-resumed"
+	SubscriptionCompleted  = "subscription-completed"
+	ReplayCompleted        = "replay-completed"
+	ReplayComplete         = "replayComplete"
+	NotificationComplete   = "notificationComplete"
+)
+
+// Subscription is parameters of create-subscription, RFC 5277, and establish-subscription and modify-subscription rpc's,
+// RFC 8639 and RFC 8641.
+// Datastore subscription is yang-push, otherwise stream is subscribed.
+type Subscription struct {
+	Stream    string
+	Datastore string
+	// Filter is subtree filter content, XPath is used instead when set
+	Filter     string
+	XPath      string
+	Namespaces map[string]string
+	// Period is periodic update interval, OnChange with Dampening is used instead when set
+	Period    time.Duration
+	OnChange  bool
+	Dampening time.Duration
+	// StartTime replays stream events since time
+	StartTime time.Time
+	StopTime  time.Time
+}
+
+// Create builds create-subscription rpc.
+func (s Subscription) Create() []byte {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<create-subscription xmlns="%s">`, NotificationNamespace))
+	if s.Stream != "" {
+		b.WriteString(fmt.Sprintf("<stream>%s</stream>", escape(s.Stream)))
+	}
+	switch {
+	case s.XPath != "":
+		b.WriteString(XPathFilter(s.XPath, s.Namespaces))
+	case s.Filter != "":
+		b.WriteString(fmt.Sprintf(`<filter type="subtree">%s</filter>`, s.Filter))
+	}
+	if !s.StartTime.IsZero() {
+		b.WriteString(fmt.Sprintf("<startTime>%s</startTime>", s.StartTime.Format(time.RFC3339Nano)))
+	}
+	if !s.StopTime.IsZero() {
+		b.WriteString(fmt.Sprintf("<stopTime>%s</stopTime>", s.StopTime.Format(time.RFC3339Nano)))
+	}
+	b.WriteString("</create-subscription>")
+	return []byte(b.String())
+}
+
+// Establish builds establish-subscription rpc.
+func (s Subscription) Establish() []byte {
+	var b strings.Builder
+	b.WriteString(s.open("establish-subscription"))
+	if s.Datastore != "" {
+		s.datastore(&b)
+	} else {
+		b.WriteString(fmt.Sprintf("<stream>%s</stream>", escape(s.Stream)))
+		switch {
+		case s.XPath != "":
+			b.WriteString(fmt.Sprintf("<stream-xpath-filter>%s</stream-xpath-filter>", escape(s.XPath)))
+		case s.Filter != "":
+			b.WriteString(fmt.Sprintf("<stream-subtree-filter>%s</stream-subtree-filter>", s.Filter))
+		}
+		if !s.StartTime.IsZero() {
+			b.WriteString(fmt.Sprintf("<replay-start-time>%s</replay-start-time>", s.StartTime.Format(time.RFC3339Nano)))
+		}
+	}
+	if !s.StopTime.IsZero() {
+		b.WriteString(fmt.Sprintf("<stop-time>%s</stop-time>", s.StopTime.Format(time.RFC3339)))
+	}
+	b.WriteString("</establish-subscription>")
+	return []byte(b.String())
+}
+
+// Modify builds modify-subscription rpc of subscription id, only datastore subscriptions can change update trigger.
+func (s Subscription) Modify(id string) []byte {
+	var b strings.Builder
+	b.WriteString(s.open("modify-subscription"))
+	b.WriteString(fmt.Sprintf("<id>%s</id>", escape(id)))
+	if s.Datastore != "" {
+		s.datastore(&b)
+	} else {
+		switch {
+		case s.XPath != "":
+			b.WriteString(fmt.Sprintf("<stream-xpath-filter>%s</stream-xpath-filter>", escape(s.XPath)))
+		case s.Filter != "":
+			b.WriteString(fmt.Sprintf("<stream-subtree-filter>%s</stream-subtree-filter>", s.Filter))
+		}
+	}
+	if !s.StopTime.IsZero() {
+		b.WriteString(fmt.Sprintf("<stop-time>%s</stop-time>", s.StopTime.Format(time.RFC3339)))
+	}
+	b.WriteString("</modify-subscription>")
+	return []byte(b.String())
+}
+
+func (s Subscription) open(operation string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<%s xmlns="%s" xmlns:yp="%s" xmlns:ds="%s"`, operation, SubscribedNotificationsNamespace, YangPushNamespace, DatastoresNamespace))
+	for _, prefix := range sortedPrefixes(s.Namespaces) {
+		b.WriteString(fmt.Sprintf(` xmlns:%s="%s"`, prefix, escape(s.Namespaces[prefix])))
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// datastore writes yang-push datastore, selection filter and update trigger, periods are in centiseconds.
+func (s Subscription) datastore(b *strings.Builder) {
+	b.WriteString(fmt.Sprintf("<yp:datastore>ds:%s</yp:datastore>", s.Datastore))
+	switch {
+	case s.XPath != "":
+		b.WriteString(fmt.Sprintf("<yp:datastore-xpath-filter>%s</yp:datastore-xpath-filter>", escape(s.XPath)))
+	case s.Filter != "":
+		b.WriteString(fmt.Sprintf("<yp:datastore-subtree-filter>%s</yp:datastore-subtree-filter>", s.Filter))
+	}
+	if s.OnChange {
+		b.WriteString("<yp:on-change>")
+		if s.Dampening > 0 {
+			b.WriteString(fmt.Sprintf("<yp:dampening-period>%d</yp:dampening-period>", s.Dampening.Milliseconds()/10))
+		}
+		b.WriteString("</yp:on-change>")
+	} else if s.Period > 0 {
+		b.WriteString(fmt.Sprintf("<yp:periodic><yp:period>%d</yp:period></yp:periodic>", s.Period.Milliseconds()/10))
+	}
+}
+
+// DeleteSubscription builds delete-subscription rpc, kill-subscription is used for subscriptions of other sessions.
+func DeleteSubscription(id string, kill bool) []byte {
+	operation := "delete-subscription"
+	if kill {
+		operation = "kill-subscription"
+	}
+	return []byte(fmt.Sprintf(`<%s xmlns="%s"><id>%s</id></%s>`, operation, SubscribedNotificationsNamespace, escape(id), operation))
+}
+
+// SubscriptionID returns id of established subscription from rpc-reply.
+func SubscriptionID(reply string) (string, error) {
+	nodes, err := xmltree.ParseReply(reply)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse establish-subscription reply, error: %v", err)
+	}
+	for _, node := range nodes {
+		if node.Name.Local == "id" {
+			return node.Text, nil
+		}
+	}
+	return "", fmt.Errorf("subscription id not found from establish-subscription reply")
+}
+
+// ReplaySupport returns true, when stream of RFC 5277 streams reply advertises replaySupport.
+func ReplaySupport(reply, stream string) (bool, error) {
+	nodes, err := xmltree.ParseReply(reply)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse streams reply, error: %v", err)
+	}
+	var supported bool
+	xmltree.Walk(nodes, func(node *xmltree.Node) {
+		if node.Name.Space != netmodNotificationNamespace || node.Name.Local != "stream" {
+			return
+		}
+		name, replay := node.Child("name"), node.Child("replaySupport")
+		if name != nil && strings.TrimSpace(name.Text) == stream && replay != nil {
+			supported = strings.TrimSpace(replay.Text) == "true"
+		}
+	})
+	return supported, nil
+}
+
+// SubscriptionEvent is subscription state change or yang-push update of notification.
+type SubscriptionEvent struct {
+	Kind   string
+	ID     string
+	Reason string
+}
+
+// ParseSubscriptionEvent parses notification, ok is false for notifications of other kinds.
+func ParseSubscriptionEvent(notification string) (SubscriptionEvent, bool) {
+	nodes, err := xmltree.Parse([]byte(notification))
+	if err != nil || len(nodes) != 1 {
+		return SubscriptionEvent{}, false
+	}
+	for _, child := range nodes[0].Children {
+		switch child.Name.Space {
+		case SubscribedNotificationsNamespace, YangPushNamespace, netmodNotificationNamespace:
+		default:
+			continue
+		}
+		event := SubscriptionEvent{Kind: child.Name.Local}
+		for _, leaf := range child.Children {
+			switch leaf.Name.Local {
+			case "id":
+				event.ID = leaf.Text
+			case "reason":
+				event.Reason = leaf.Text
+			}
+		}
+		return event, true
+	}
+	return SubscriptionEvent{}, false
+}
+
+// EventName returns name of event element of notification, empty if notification is not parsed.
+func EventName(notification string) string {
+	nodes, err := xmltree.Parse([]byte(notification))
+	if err != nil || len(nodes) != 1 {
+		return ""
+	}
+	for _, child := range nodes[0].Children {
+		if child.Name.Local != "eventTime" {
+			return child.Name.Local
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionRPCs(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		rpc      []byte
+		expected string
+	}{
+		{
+			name:     "create with subtree filter and window",
+			rpc:      Subscription{Stream: "NETCONF", Filter: "<event/>", StartTime: start, StopTime: start.Add(time.Hour)}.Create(),
+			expected: `<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><stream>NETCONF</stream><filter type="subtree"><event/></filter><startTime>2024-05-01T10:00:00Z</startTime><stopTime>2024-05-01T11:00:00Z</stopTime></create-subscription>`,
+		},
+		{
+			name:     "create with xpath filter",
+			rpc:      Subscription{XPath: "/a:event", Namespaces: map[string]string{"a": "urn:a"}}.Create(),
+			expected: `<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><filter type="xpath" xmlns:a="urn:a" select="/a:event"/></create-subscription>`,
+		},
+		{
+			name:     "establish periodic datastore",
+			rpc:      Subscription{Datastore: "operational", XPath: "/if:interfaces", Period: 10 * time.Second}.Establish(),
+			expected: `<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications" xmlns:yp="urn:ietf:params:xml:ns:yang:ietf-yang-push" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores"><yp:datastore>ds:operational</yp:datastore><yp:datastore-xpath-filter>/if:interfaces</yp:datastore-xpath-filter><yp:periodic><yp:period>1000</yp:period></yp:periodic></establish-subscription>`,
+		},
+		{
+			name:     "establish stream with replay",
+			rpc:      Subscription{Stream: "NETCONF", StartTime: start}.Establish(),
+			expected: `<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications" xmlns:yp="urn:ietf:params:xml:ns:yang:ietf-yang-push" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores"><stream>NETCONF</stream><replay-start-time>2024-05-01T10:00:00Z</replay-start-time></establish-subscription>`,
+		},
+		{
+			name:     "kill",
+			rpc:      DeleteSubscription("42", true),
+			expected: `<kill-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"><id>42</id></kill-subscription>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, string(test.rpc))
+		})
+	}
+}
+
+func TestParseSubscriptionEvent(t *testing.T) {
+	terminated := `<notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><eventTime>2024-05-01T10:00:00Z</eventTime>` +
+		`<subscription-terminated xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"><id>42</id><reason>sn:filter-unavailable</reason></subscription-terminated></notification>`
+	event, ok := ParseSubscriptionEvent(terminated)
+	assert.True(t, ok)
+	assert.Equal(t, SubscriptionEvent{Kind: SubscriptionTerminated, ID: "42", Reason: "sn:filter-unavailable"}, event)
+	assert.Equal(t, SubscriptionTerminated, EventName(terminated))
+
+	complete := `<notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><eventTime>2024-05-01T10:00:00Z</eventTime>` +
+		`<notificationComplete xmlns="urn:ietf:params:xml:ns:netmod:notification"/></notification>`
+	event, ok = ParseSubscriptionEvent(complete)
+	assert.True(t, ok)
+	assert.Equal(t, NotificationComplete, event.Kind)
+
+	change := `<notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><eventTime>2024-05-01T10:00:00Z</eventTime>` +
+		`<netconf-config-change xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-notifications"><datastore>running</datastore></netconf-config-change></notification>`
+	_, ok = ParseSubscriptionEvent(change)
+	assert.False(t, ok)
+	assert.Equal(t, "netconf-config-change", EventName(change))
+}
+
+func TestReplaySupport(t *testing.T) {
+	reply := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><data>` +
+		`<netconf xmlns="urn:ietf:params:xml:ns:netmod:notification"><streams>` +
+		`<stream><name>NETCONF</name><description>default</description><replaySupport>true</replaySupport></stream>` +
+		`<stream><name>syslog</name><replaySupport>false</replaySupport></stream>` +
+		`<stream><name>snmp</name></stream>` +
+		`</streams></netconf></data></rpc-reply>`
+
+	for stream, expected := range map[string]bool{"NETCONF": true, "syslog": false, "snmp": false, "other": false} {
+		supported, err := ReplaySupport(reply, stream)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, supported, stream)
+	}
+	_, err := ReplaySupport("<rpc-reply>", "NETCONF")
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execSink runs command per event, event data is written to stdin and event fields are set as environment variables.
+type execSink struct {
+	config Config
+}
+
+func newExec(c Config) (Sink, error) {
+	if len(c.Command) == 0 {
+		return nil, fmt.Errorf("sink %s: command is required", c.Name)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	return &execSink{config: c}, nil
+}
+
+func (s *execSink) Write(event Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.config.Command[0], s.config.Command[1:]...)
+	cmd.Stdin = strings.NewReader(event.Data)
+	cmd.Env = append(os.Environ(),
+		"NETCONF_HOST="+event.Host,
+		"NETCONF_STREAM="+event.Stream,
+		"NETCONF_EVENT="+event.Name,
+		"NETCONF_EVENT_TIME="+event.Time.Format(time.RFC3339Nano),
+		"NETCONF_FORMAT="+event.Format,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command %s failed, error: %v, stderr: %s", s.config.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (s *execSink) Close() error {
+	return nil
+}
@@ -0,0 +1,198 @@
+package sink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// maxOpenFiles is count of files kept open, least recently written file is closed when new path is written,
+// e.g. when path template contains date.
+const maxOpenFiles = 64
+
+// fileSink writes events to files rendered from path template, files are kept open and rotated by size and age.
+type fileSink struct {
+	config   Config
+	template *template.Template
+	files    map[string]*rotatingFile
+}
+
+type rotatingFile struct {
+	path    string
+	file    *os.File
+	size    int64
+	opened  time.Time
+	written time.Time
+}
+
+func newFile(c Config) (Sink, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("sink %s: path is required", c.Name)
+	}
+	switch c.Format {
+	case "":
+		c.Format = FormatXML
+	case FormatXML, FormatNDJSON:
+	default:
+		return nil, fmt.Errorf("sink %s: unsupported format %q, use xml|ndjson", c.Name, c.Format)
+	}
+	tmpl, err := template.New(c.Name).Option("missingkey=error").Parse(c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: failed to parse path template, %v", c.Name, err)
+	}
+	return &fileSink{config: c, template: tmpl, files: make(map[string]*rotatingFile)}, nil
+}
+
+func (s *fileSink) Write(event Event) error {
+	var path bytes.Buffer
+	if err := s.template.Execute(&path, event); err != nil {
+		return fmt.Errorf("failed to render path, error: %v", err)
+	}
+
+	line := []byte(event.Data)
+	if s.config.Format == FormatNDJSON {
+		b, err := encode(event)
+		if err != nil {
+			return err
+		}
+		line = b
+	}
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		line = append(line, '\n')
+	}
+
+	f, found := s.files[path.String()]
+	if !found {
+		if len(s.files) >= maxOpenFiles {
+			s.closeIdle()
+		}
+		f = &rotatingFile{path: path.String()}
+		if err := f.open(); err != nil {
+			return err
+		}
+		s.files[f.path] = f
+	}
+	if f.size > 0 && ((s.config.MaxSize > 0 && f.size+int64(len(line)) > s.config.MaxSize) ||
+		(s.config.MaxAge > 0 && time.Since(f.opened) > s.config.MaxAge)) {
+		if err := f.rotate(s.config.Gzip); err != nil {
+			if f.file == nil {
+				// file is opened again by next write
+				delete(s.files, f.path)
+			}
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	f.written = time.Now()
+	return err
+}
+
+// closeIdle closes least recently written file.
+func (s *fileSink) closeIdle() {
+	var idle *rotatingFile
+	for _, f := range s.files {
+		if idle == nil || f.written.Before(idle.written) {
+			idle = f
+		}
+	}
+	if idle != nil {
+		_ = idle.file.Close()
+		delete(s.files, idle.path)
+	}
+}
+
+func (s *fileSink) Close() error {
+	var err error
+	for _, f := range s.files {
+		if e := f.file.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory, error: %v", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file, error: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat file, error: %v", err)
+	}
+	f.file, f.size, f.opened = file, info.Size(), time.Now()
+	return nil
+}
+
+// rotate renames file with timestamp suffix, optionally compressing it, and opens new file.
+// File is opened again also when renaming or compressing fails, file is nil only when opening fails.
+func (f *rotatingFile) rotate(compress bool) error {
+	err := f.archive(compress)
+	if openErr := f.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+func (f *rotatingFile) archive(compress bool) error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close file, error: %v", err)
+	}
+	rotated := fmt.Sprintf("%s.%s", f.path, time.Now().Format("20060102T150405.000"))
+	// files rotated within same millisecond get sequence number
+	for i := 1; exists(rotated) || exists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s.%s-%d", f.path, time.Now().Format("20060102T150405.000"), i)
+	}
+	if err := os.Rename(f.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate file, error: %v", err)
+	}
+	if compress {
+		return gzipFile(rotated)
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open rotated file, error: %v", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return fmt.Errorf("failed to create compressed file, error: %v", err)
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to compress rotated file, error: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to compress rotated file, error: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close compressed file, error: %v", err)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,258 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TypeFile    = "file"
+	TypeSyslog  = "syslog"
+	TypeWebhook = "webhook"
+	TypeExec    = "exec"
+
+	FormatXML    = "xml"
+	FormatNDJSON = "ndjson"
+
+	defaultBuffer = 1000
+)
+
+// Event is notification delivered to sinks, data is notification formatted with output format.
+type Event struct {
+	Host   string    `json:"host"`
+	Stream string    `json:"stream"`
+	Name   string    `json:"event"`
+	Time   time.Time `json:"eventTime"`
+	Format string    `json:"format"`
+	Data   string    `json:"data"`
+}
+
+// Sink is notification backend, Write is called from one goroutine at time.
+type Sink interface {
+	Write(event Event) error
+	Close() error
+}
+
+// Config is sink of sinks file, empty streams and groups match all. Group of device is inventory variable "group".
+type Config struct {
+	Name    string   `yaml:"name"`
+	Type    string   `yaml:"type"`
+	Streams []string `yaml:"streams"`
+	Groups  []string `yaml:"groups"`
+	// Buffer is count of events buffered, before events are dropped
+	Buffer int `yaml:"buffer"`
+
+	// file, path is template of event fields, e.g. {{.Host}}-{{.Stream}}.xml
+	Path    string        `yaml:"path"`
+	Format  string        `yaml:"format"`
+	MaxSize int64         `yaml:"max-size"`
+	MaxAge  time.Duration `yaml:"max-age"`
+	Gzip    bool          `yaml:"gzip"`
+
+	// syslog, address is udp://host:port or tcp://host:port
+	Address  string `yaml:"address"`
+	Facility int    `yaml:"facility"`
+	AppName  string `yaml:"app-name"`
+
+	// webhook
+	URL           string            `yaml:"url"`
+	Headers       map[string]string `yaml:"headers"`
+	BatchSize     int               `yaml:"batch-size"`
+	BatchInterval time.Duration     `yaml:"batch-interval"`
+	Retries       int               `yaml:"retries"`
+
+	// exec, event data is written to stdin of command
+	Command []string `yaml:"command"`
+
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Matches returns true, when sink is configured for stream and device group.
+func (c Config) Matches(stream, group string) bool {
+	return (len(c.Streams) == 0 || slices.Contains(c.Streams, stream)) &&
+		(len(c.Groups) == 0 || slices.Contains(c.Groups, group))
+}
+
+// Load reads sinks file.
+func Load(path string) ([]Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sinks file, %v", err)
+	}
+
+	var file struct {
+		Sinks []Config `yaml:"sinks"`
+	}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse sinks file, %v", err)
+	}
+	if len(file.Sinks) == 0 {
+		return nil, fmt.Errorf("no sinks found from %s", path)
+	}
+	for i := range file.Sinks {
+		if file.Sinks[i].Name == "" {
+			file.Sinks[i].Name = fmt.Sprintf("%s-%d", file.Sinks[i].Type, i+1)
+		}
+	}
+	return file.Sinks, nil
+}
+
+// New creates sink of config.
+func New(c Config) (Sink, error) {
+	switch c.Type {
+	case TypeFile:
+		return newFile(c)
+	case TypeSyslog:
+		return newSyslog(c)
+	case TypeWebhook:
+		return newWebhook(c)
+	case TypeExec:
+		return newExec(c)
+	default:
+		return nil, fmt.Errorf("sink %s: unsupported type %q, use file|syslog|webhook|exec", c.Name, c.Type)
+	}
+}
+
+// Set routes events to buffered sinks matching stream and device group.
+type Set struct {
+	configs []Config
+	sinks   []Sink
+}
+
+// Open creates buffered sinks of configs.
+func Open(configs []Config) (*Set, error) {
+	set := &Set{}
+	for _, c := range configs {
+		s, err := New(c)
+		if err != nil {
+			_ = set.Close()
+			return nil, err
+		}
+		size := c.Buffer
+		if size <= 0 {
+			size = defaultBuffer
+		}
+		set.configs = append(set.configs, c)
+		set.sinks = append(set.sinks, Buffered(c.Name, s, size))
+	}
+	return set, nil
+}
+
+// Write queues event to matching sinks, it never blocks.
+func (s *Set) Write(event Event, group string) {
+	for i, c := range s.configs {
+		if c.Matches(event.Stream, group) {
+			_ = s.sinks[i].Write(event)
+		}
+	}
+}
+
+// Close flushes and closes all sinks.
+func (s *Set) Close() error {
+	var errs []string
+	for i, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", s.configs[i].Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close sinks, %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+type buffered struct {
+	name    string
+	sink    Sink
+	events  chan Event
+	done    chan struct{}
+	dropped atomic.Int64
+
+	// mu guards closing events, as handlers may still write events while sink is closed
+	mu     sync.Mutex
+	closed bool
+}
+
+// Buffered wraps sink with bounded buffer written by own goroutine, events are dropped when buffer is full,
+// so that slow sink never blocks caller.
+func Buffered(name string, sink Sink, size int) Sink {
+	b := &buffered{
+		name:   name,
+		sink:   sink,
+		events: make(chan Event, size),
+		done:   make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *buffered) Write(event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return fmt.Errorf("sink %s is closed", b.name)
+	}
+
+	select {
+	case b.events <- event:
+		return nil
+	default:
+		// warn on first drop and then once per buffer size
+		if dropped := b.dropped.Add(1); dropped == 1 || dropped%int64(cap(b.events)) == 0 {
+			log.Warnf("Sink %s buffer is full, dropped %d events", b.name, dropped)
+		}
+		return fmt.Errorf("sink %s buffer is full", b.name)
+	}
+}
+
+func (b *buffered) run() {
+	defer close(b.done)
+	for event := range b.events {
+		if err := b.sink.Write(event); err != nil {
+			log.Warnf("Sink %s failed to write event: %v", b.name, err)
+		}
+	}
+}
+
+func (b *buffered) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.events)
+	b.mu.Unlock()
+
+	<-b.done
+	if dropped := b.dropped.Load(); dropped > 0 {
+		log.Warnf("Sink %s dropped %d events in total", b.name, dropped)
+	}
+	return b.sink.Close()
+}
+
+// encode encodes event as json, json data is embedded as is.
+func encode(event Event) ([]byte, error) {
+	type record struct {
+		Event
+		Data any `json:"data"`
+	}
+	r := record{Event: event, Data: strings.TrimSuffix(event.Data, "\n")}
+	if event.Format == "json" && json.Valid([]byte(event.Data)) {
+		r.Data = json.RawMessage(event.Data)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event, error: %v", err)
+	}
+	return b, nil
+}
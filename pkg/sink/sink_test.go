@@ -0,0 +1,212 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var event = Event{
+	Host:   "192.168.1.1",
+	Stream: "NETCONF",
+	Name:   "netconf-config-change",
+	Time:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	Format: "xml",
+	Data:   "<notification/>",
+}
+
+func TestConfigMatches(t *testing.T) {
+	tests := []struct {
+		config  Config
+		stream  string
+		group   string
+		matches bool
+	}{
+		{config: Config{}, stream: "NETCONF", group: "", matches: true},
+		{config: Config{Streams: []string{"NETCONF"}}, stream: "NETCONF", group: "core", matches: true},
+		{config: Config{Streams: []string{"syslog"}}, stream: "NETCONF", group: "core", matches: false},
+		{config: Config{Groups: []string{"core"}}, stream: "NETCONF", group: "core", matches: true},
+		{config: Config{Groups: []string{"core"}}, stream: "NETCONF", group: "edge", matches: false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.matches, test.config.Matches(test.stream, test.group), "config %+v", test.config)
+	}
+}
+
+func TestLoadExample(t *testing.T) {
+	configs, err := Load("../../examples/sinks.yaml")
+	assert.NoError(t, err)
+	assert.Len(t, configs, 4)
+	assert.Equal(t, 24*time.Hour, configs[0].MaxAge)
+	assert.Equal(t, 10*time.Second, configs[2].BatchInterval)
+
+	set, err := Open(configs)
+	assert.NoError(t, err)
+	assert.NoError(t, set.Close())
+}
+
+func TestFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newFile(Config{Name: "file", Path: filepath.Join(dir, "{{.Host}}.ndjson"), Format: FormatNDJSON, MaxSize: 200, Gzip: true})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.Write(event))
+	}
+	assert.NoError(t, s.Close())
+
+	current, err := os.ReadFile(filepath.Join(dir, "192.168.1.1.ndjson"))
+	assert.NoError(t, err)
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(strings.Split(string(current), "\n")[0]), &record))
+	assert.Equal(t, "netconf-config-change", record["event"])
+	assert.Equal(t, "<notification/>", record["data"])
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "192.168.1.1.ndjson.*.gz"))
+	assert.NoError(t, err)
+	assert.Len(t, rotated, 2)
+}
+
+func TestFileRotationFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "192.168.1.1.xml")
+	s, err := newFile(Config{Name: "file", Path: filepath.Join(dir, "{{.Host}}.xml"), MaxSize: 20})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Write(event))
+
+	// rename of removed file fails, file is opened again for later writes
+	assert.NoError(t, os.Remove(path))
+	assert.Error(t, s.Write(event))
+	assert.NoError(t, s.Write(event))
+	assert.NoError(t, s.Close())
+
+	current, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "<notification/>\n", string(current))
+}
+
+func TestFileOpenLimit(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newFile(Config{Name: "file", Path: filepath.Join(dir, "{{.Host}}.xml")})
+	assert.NoError(t, err)
+	for i := 0; i <= maxOpenFiles; i++ {
+		e := event
+		e.Host = fmt.Sprintf("10.0.0.%d", i)
+		assert.NoError(t, s.Write(e))
+	}
+	assert.Len(t, s.(*fileSink).files, maxOpenFiles)
+	assert.NotContains(t, s.(*fileSink).files, filepath.Join(dir, "10.0.0.0.xml"))
+
+	// closed file is opened again and appended
+	assert.NoError(t, s.Write(event))
+	e := event
+	e.Host = "10.0.0.0"
+	assert.NoError(t, s.Write(e))
+	assert.NoError(t, s.Write(e))
+	assert.NoError(t, s.Close())
+
+	current, err := os.ReadFile(filepath.Join(dir, "10.0.0.0.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("<notification/>\n", 3), string(current))
+}
+
+func TestSyslogMessage(t *testing.T) {
+	s, err := newSyslog(Config{Name: "syslog", Address: "udp://127.0.0.1:514"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<134>1 2024-05-01T10:00:00Z 192.168.1.1 netconf - netconf-config-change - <notification/>", s.(*syslogSink).message(event))
+
+	nano := event
+	nano.Time = nano.Time.Add(123456789 * time.Nanosecond)
+	assert.Contains(t, s.(*syslogSink).message(nano), " 2024-05-01T10:00:00.123456Z ")
+
+	_, err = newSyslog(Config{Name: "syslog", Address: "127.0.0.1:514"})
+	assert.Error(t, err)
+}
+
+func TestBufferedDrops(t *testing.T) {
+	blocked := &blockingSink{release: make(chan struct{})}
+	s := Buffered("blocking", blocked, 1)
+	// first event is taken by writer goroutine, second is buffered
+	assert.NoError(t, s.Write(event))
+	assert.Eventually(t, func() bool { return blocked.started() }, time.Second, time.Millisecond)
+	assert.NoError(t, s.Write(event))
+	assert.Error(t, s.Write(event))
+
+	close(blocked.release)
+	assert.NoError(t, s.Close())
+	assert.Equal(t, 2, blocked.count)
+
+	// late writes after close are dropped
+	assert.Error(t, s.Write(event))
+}
+
+func TestWebhookBatches(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]Event
+		calls   int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var batch []Event
+		_ = json.Unmarshal(body, &batch)
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	s, err := newWebhook(Config{Name: "webhook", URL: server.URL, BatchSize: 2, BatchInterval: time.Hour})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.Write(event))
+	}
+	assert.NoError(t, s.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, calls)
+	if assert.Len(t, batches, 2) {
+		assert.Len(t, batches[0], 2)
+		assert.Len(t, batches[1], 1)
+		assert.Equal(t, event.Host, batches[1][0].Host)
+	}
+}
+
+type blockingSink struct {
+	mu      sync.Mutex
+	release chan struct{}
+	count   int
+}
+
+func (s *blockingSink) started() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count > 0
+}
+
+func (s *blockingSink) Write(Event) error {
+	s.mu.Lock()
+	s.count++
+	s.mu.Unlock()
+	<-s.release
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	return nil
+}
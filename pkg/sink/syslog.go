@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFacility = 16 // local0
+	severityInfo    = 6
+	nilValue        = "-"
+	// RFC 5424 TIME-SECFRAC allows at most 6 fractional digits
+	timeFormat = "2006-01-02T15:04:05.999999Z07:00"
+)
+
+// syslogSink sends events as RFC 5424 messages, over tcp messages are framed with octet counting, RFC 6587.
+type syslogSink struct {
+	config  Config
+	network string
+	address string
+	conn    net.Conn
+}
+
+func newSyslog(c Config) (Sink, error) {
+	u, err := url.Parse(c.Address)
+	if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+		return nil, fmt.Errorf("sink %s: invalid address %q, use udp://host:port or tcp://host:port", c.Name, c.Address)
+	}
+	if c.Facility == 0 {
+		c.Facility = defaultFacility
+	}
+	if c.Facility < 0 || c.Facility > 23 {
+		return nil, fmt.Errorf("sink %s: invalid facility %d", c.Name, c.Facility)
+	}
+	if c.AppName == "" {
+		c.AppName = "netconf"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	return &syslogSink{config: c, network: u.Scheme, address: u.Host}, nil
+}
+
+func (s *syslogSink) Write(event Event) error {
+	message := s.message(event)
+	if s.network == "tcp" {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	// connection is redialed once, if write fails
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil {
+			conn, err := net.DialTimeout(s.network, s.address, s.config.Timeout)
+			if err != nil {
+				return fmt.Errorf("failed to dial syslog, error: %v", err)
+			}
+			s.conn = conn
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.config.Timeout))
+		_, err := s.conn.Write([]byte(message))
+		if err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+		if attempt > 0 {
+			return fmt.Errorf("failed to send syslog message, error: %v", err)
+		}
+	}
+}
+
+// message formats RFC 5424 message, device is hostname and event name is msgid.
+func (s *syslogSink) message(event Event) string {
+	timestamp := nilValue
+	if !event.Time.IsZero() {
+		timestamp = event.Time.Format(timeFormat)
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		s.config.Facility*8+severityInfo,
+		timestamp,
+		header(event.Host, 255),
+		header(s.config.AppName, 48),
+		nilValue,
+		header(event.Name, 32),
+		nilValue,
+		strings.TrimSuffix(event.Data, "\n"),
+	)
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// header returns printable header field truncated to limit, nil value for empty field.
+func header(value string, limit int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return nilValue
+	}
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
@@ -0,0 +1,147 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// webhookSink posts batches of events as json array, batch is sent when full or after batch interval.
+// Failed posts are retried with backoff on network errors, 429 and 5xx responses, negative retries disables retrying.
+type webhookSink struct {
+	config Config
+	client *http.Client
+
+	mu    sync.Mutex
+	batch []json.RawMessage
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newWebhook(c Config) (Sink, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("sink %s: url is required", c.Name)
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.BatchInterval <= 0 {
+		c.BatchInterval = 5 * time.Second
+	}
+	if c.Retries < 0 {
+		c.Retries = 0
+	} else if c.Retries == 0 {
+		c.Retries = 3
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	s := &webhookSink{
+		config: c,
+		client: &http.Client{Timeout: c.Timeout},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.flusher()
+	return s, nil
+}
+
+func (s *webhookSink) Write(event Event) error {
+	b, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batch = append(s.batch, b)
+	if len(s.batch) >= s.config.BatchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *webhookSink) flusher() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.BatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			err := s.flush()
+			s.mu.Unlock()
+			if err != nil {
+				log.Warnf("Sink %s failed to write events: %v", s.config.Name, err)
+			}
+		}
+	}
+}
+
+// flush posts batch, it is called with lock held. Batch is discarded after retries are exhausted.
+func (s *webhookSink) flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(s.batch)
+	count := len(s.batch)
+	s.batch = nil
+	if err != nil {
+		return fmt.Errorf("failed to encode batch, error: %v", err)
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.config.Retries {
+			return fmt.Errorf("failed to post batch of %d events after %d attempts, error: %v", count, attempt+1, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends body, returns true when failure is retryable.
+func (s *webhookSink) post(body []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range s.config.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %s", response.Status)
+}
+
+func (s *webhookSink) Close() error {
+	close(s.stop)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush()
+}
@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/networkguild/netconf"
+	"github.com/networkguild/netconf-cli/pkg/config"
+	"github.com/networkguild/netconf-cli/pkg/utils"
+)
+
+const (
+	StatusReverted      = "reverted"
+	StatusRestoreFailed = "restore-failed"
+)
+
+// Snapshot is running configuration stored before device is modified.
+type Snapshot struct {
+	Path string
+	data string
+}
+
+// Take fetches running configuration from device and stores it to dir.
+func Take(ctx context.Context, device *config.Device, session *netconf.Session, dir string) (*Snapshot, error) {
+	start := time.Now()
+	reply, err := session.GetConfig(ctx, netconf.Running)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get running config for snapshot, error: %v", err)
+	}
+
+	data, err := utils.ExtractData(reply.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse running config for snapshot, error: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory, error: %v", err)
+	}
+
+	name := fmt.Sprintf("%s-snapshot-%s.xml", device.IP, time.Now().Format("20060102T150405"))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(utils.FormatXML(data)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot, error: %v", err)
+	}
+
+	device.Result.SetDetail("snapshot", path)
+	device.Log.Infof("Saved running config snapshot to file %s, took %.3f seconds", path, time.Since(start).Seconds())
+	return &Snapshot{Path: path, data: data}, nil
+}
+
+// Restore pushes snapshot back to device, using copy-config with inline config and
+// falling back to edit-config replace, if device does not accept inline copy-config.
+// Caller holds datastore lock taken before snapshot. Outcome is recorded to device result.
+func (s *Snapshot) Restore(device *config.Device, session *netconf.Session) error {
+	ctx, cancel := context.WithTimeout(device.Ctx, 5*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	if err := Replace(ctx, device, session, s.data, true); err != nil {
+		device.Result.Status = StatusRestoreFailed
+		device.Result.SetDetail("restore", err.Error())
+		device.Log.Errorf("Failed to restore snapshot %s: %v", s.Path, err)
+		return err
+	}
+
+	device.Result.Status = StatusReverted
+	device.Result.SetDetail("restore", "restored from "+s.Path)
+	device.Log.Warnf("Restored config from snapshot %s, took %.3f seconds", s.Path, time.Since(start).Seconds())
+	return nil
+}
+
+// Replace replaces device config with data, using copy-config with inline config or edit-config replace as fallback.
+// Candidate datastore is used and committed, when supported. Datastore is locked during replace, unless locked is set,
+// when caller already holds the lock and releases it.
+func Replace(ctx context.Context, device *config.Device, session *netconf.Session, data string, locked bool) error {
+	datastore := netconf.Running
+	if slices.Contains(session.ServerCapabilities(), netconf.CandidateCapability) {
+		datastore = netconf.Candidate
+	}
+	if !locked {
+		device.Log.Debugf("Locking %s datastore", datastore)
+		if err := session.Lock(ctx, datastore); err != nil {
+			return fmt.Errorf("failed to lock %s datastore, error: %v", datastore, err)
+		}
+		defer func() {
+			if err := session.Unlock(ctx, datastore); err != nil {
+				device.Log.Warnf("Failed to unlock %s datastore: %v", datastore, err)
+			}
+		}()
+	}
+
+	if datastore == netconf.Candidate {
+		device.Log.Debug("Discarding uncommitted changes")
+		if _, err := session.Dispatch(ctx, []byte("<discard-changes/>")); err != nil {
+			return fmt.Errorf("failed to discard changes, error: %v", err)
+		}
+	}
+
+	copyConfig := fmt.Sprintf("<copy-config><target><%s/></target><source><config>%s</config></source></copy-config>", datastore, data)
+	if _, err := session.Dispatch(ctx, []byte(copyConfig)); err != nil {
+		device.Log.Debugf("Inline copy-config failed, falling back to edit-config replace: %v", err)
+		if err := session.EditConfig(ctx,
+			datastore,
+			[]byte(fmt.Sprintf("<config>%s</config>", data)),
+			netconf.WithDefaultMergeStrategy(netconf.MergeStrategy("replace")),
+		); err != nil {
+			return fmt.Errorf("failed to replace %s config, error: %v", datastore, err)
+		}
+	}
+
+	if datastore == netconf.Candidate {
+		if err := session.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit restored config, error: %v", err)
+		}
+	}
+	return nil
+}
@@ -21,7 +21,8 @@ type jumpConfig struct {
 	sshCfg  *ssh.ClientConfig
 }
 
-func (c *Client) dialJumpHost(command string, device *config.Device) (*ssh.Client, error) {
+// dialJumpHost returns connection to jump host and key of it in proxies.
+func (c *Client) dialJumpHost(command string, device *config.Device) (*ssh.Client, string, error) {
 	// assuming that ProxyCommand is in format `ProxyCommand ssh -W %h:%p proxy` or `ssh proxy -W %h:%p`
 	proxyCommand := strings.Split(command, " ")
 	sshConfigProxy := parseJumpHost(proxyCommand[1:], c.sshConfig)
@@ -29,41 +30,58 @@ func (c *Client) dialJumpHost(command string, device *config.Device) (*ssh.Clien
 	if !c.multiplexing {
 		jump, err := c.getJumpHostConfigs(sshConfigProxy)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		device.Log.Debugf("Connecting to proxy %s", jump.address)
 		uniqueConn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", jump.address, jump.port), jump.sshCfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to dial tunnel host: %s, %v", jump.address, err)
+			return nil, "", fmt.Errorf("failed to dial tunnel host: %s, %v", jump.address, err)
 		}
 		device.Log.Infof("Connected to proxy %s", jump.address)
 		if c.keepalive {
 			go keepAlive(uniqueConn)
 		}
 		c.proxies.Set(device.IP, uniqueConn)
-		return uniqueConn, nil
+		return uniqueConn, device.IP, nil
 	}
 
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	conn, found := c.proxies.Get(sshConfigProxy.HostName)
+	key := sshConfigProxy.HostName
+	conn, found := c.proxies.Get(key)
 	if !found {
 		jump, err := c.getJumpHostConfigs(sshConfigProxy)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		log.Debugf("Connecting to proxy %s", jump.address)
 		conn, err = ssh.Dial("tcp", fmt.Sprintf("%s:%d", jump.address, jump.port), jump.sshCfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to dial tunnel host: %s, %v", jump.address, err)
+			return nil, "", fmt.Errorf("failed to dial tunnel host: %s, %v", jump.address, err)
 		}
 		log.Infof("Connected to proxy %s", jump.address)
 		if c.keepalive {
 			go keepAlive(conn)
 		}
-		c.proxies.Set(sshConfigProxy.HostName, conn)
+		c.proxies.Set(key, conn)
+
+		// closed connection, e.g. by failed keepalive, is evicted so that next device redials proxy
+		go func(conn *ssh.Client) {
+			_ = conn.Wait()
+			c.dropProxy(key, conn)
+		}(conn)
+	}
+	return conn, key, nil
+}
+
+// dropProxy removes shared proxy connection from cache and closes it, unless it was already replaced.
+func (c *Client) dropProxy(key string, conn *ssh.Client) {
+	c.lock.Lock()
+	if cached, found := c.proxies.Get(key); found && cached == conn {
+		c.proxies.Del(key)
 	}
-	return conn, nil
+	c.lock.Unlock()
+	_ = conn.Close()
 }
 
 const defaultSSHPort = 22
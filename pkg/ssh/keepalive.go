@@ -6,14 +6,33 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
-const keepAliveName = "NETCONF_SUBSCRIPTION_KEEPALIVE"
+const (
+	keepAliveName     = "NETCONF_SUBSCRIPTION_KEEPALIVE"
+	keepAliveInterval = 30 * time.Second
+	keepAliveTimeout  = 15 * time.Second
+)
 
+// keepAlive sends keepalive requests, connection is closed when request fails or is not replied in time,
+// so that dead transport is noticed by waiters of connection.
 func keepAlive(conn *ssh.Client) {
-	ticker := time.NewTicker(2 * time.Minute)
+	ticker := time.NewTicker(keepAliveInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		if _, _, err := conn.SendRequest(keepAliveName, true, nil); err != nil {
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := conn.SendRequest(keepAliveName, true, nil)
+			reply <- err
+		}()
+
+		select {
+		case err := <-reply:
+			if err != nil {
+				_ = conn.Close()
+				return
+			}
+		case <-time.After(keepAliveTimeout):
+			_ = conn.Close()
 			return
 		}
 	}
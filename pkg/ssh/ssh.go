@@ -116,12 +116,13 @@ func (c *Client) parseConnection(device *config.Device) (*ssh.Client, error) {
 		if match {
 			var useProxy bool
 			var proxyConn *ssh.Client
+			var proxyKey string
 			if host.ProxyCommand != "" {
 				if !strings.ContainsAny(host.ProxyCommand, "-W") {
 					return nil, fmt.Errorf("only proxy command with -W is supported, got: %s", host.ProxyCommand)
 				}
 
-				if proxyConn, err = c.dialJumpHost(host.ProxyCommand, device); err != nil {
+				if proxyConn, proxyKey, err = c.dialJumpHost(host.ProxyCommand, device); err != nil {
 					return nil, err
 				}
 				useProxy = true
@@ -155,13 +156,32 @@ func (c *Client) parseConnection(device *config.Device) (*ssh.Client, error) {
 
 			if useProxy {
 				conn, err := proxyConn.Dial("tcp", deviceAddr)
+				var channelErr *ssh.OpenChannelError
+				if err != nil && c.multiplexing && !errors.As(err, &channelErr) {
+					// shared proxy connection is dead, evict it and redial once
+					device.Log.Warnf("Proxy connection failed, reconnecting proxy: %v", err)
+					c.dropProxy(proxyKey, proxyConn)
+					if proxyConn, proxyKey, err = c.dialJumpHost(host.ProxyCommand, device); err != nil {
+						return nil, err
+					}
+					conn, err = proxyConn.Dial("tcp", deviceAddr)
+				}
 				if err != nil {
+					if c.multiplexing {
+						if !errors.As(err, &channelErr) {
+							c.dropProxy(proxyKey, proxyConn)
+						}
+						return nil, err
+					}
 					return nil, errors.Join(err, proxyConn.Close())
 				}
 
 				device.Log.Debugf("Connecting to device %s through proxy", deviceAddr)
 				sshConn, chans, reqs, err := ssh.NewClientConn(conn, deviceAddr, deviceConf)
 				if err != nil {
+					if c.multiplexing {
+						return nil, errors.Join(err, conn.Close())
+					}
 					return nil, errors.Join(err, proxyConn.Close())
 				}
 				device.Log.Infof("Connected to device %s through proxy", deviceAddr)
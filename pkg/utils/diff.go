@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff returns line based unified diff of a and b, empty if equal.
+func UnifiedDiff(nameA, nameB, a, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var (
+		out     strings.Builder
+		changed bool
+	)
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		if !changed {
+			out.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", nameA, nameB))
+			changed = true
+		}
+
+		// hunk spans changes, which are closer than two contexts to each other
+		start := max(i-diffContext, 0)
+		last := i
+		for j := i + 1; j < len(lines) && j-last <= 2*diffContext; j++ {
+			if lines[j].op != ' ' {
+				last = j
+			}
+		}
+		end := min(last+1+diffContext, len(lines))
+
+		lineA, lineB := 1, 1
+		for _, l := range lines[:start] {
+			if l.op != '+' {
+				lineA++
+			}
+			if l.op != '-' {
+				lineB++
+			}
+		}
+		var countA, countB int
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				countA++
+			}
+			if l.op != '-' {
+				countB++
+			}
+		}
+		out.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", lineA, countA, lineB, countB))
+		for _, l := range lines[start:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines computes shortest edit script of lines with Myers' algorithm in linear space,
+// common prefix and suffix are skipped and rest is split recursively at middle of edit path.
+func diffLines(a, b []string) []diffLine {
+	return appendDiff(nil, a, b)
+}
+
+func appendDiff(lines []diffLine, a, b []string) []diffLine {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, l := range a[:prefix] {
+		lines = append(lines, diffLine{' ', l})
+	}
+
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	i, j := -1, -1
+	if len(x) > 0 && len(y) > 0 {
+		i, j = bisect(x, y)
+	}
+	if i <= 0 && j <= 0 || i >= len(x) && j >= len(y) {
+		// nothing in common, or no split making progress
+		for _, l := range x {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range y {
+			lines = append(lines, diffLine{'+', l})
+		}
+	} else {
+		lines = appendDiff(lines, x[:i], y[:j])
+		lines = appendDiff(lines, x[i:], y[j:])
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	return lines
+}
+
+// bisect finds point where forward and backward furthest reaching paths of a and b overlap,
+// returns -1 when a and b have nothing in common. Diagonals leaving edit graph are skipped.
+func bisect(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	forward, backward := make([]int, size), make([]int, size)
+	for k := range forward {
+		forward[k], backward[k] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// with odd delta paths overlap while extending forward path, otherwise backward path
+	odd := delta%2 != 0
+	var fStart, fEnd, bStart, bEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			idx := offset + k
+			var x int
+			if k == -d || k != d && forward[idx-1] < forward[idx+1] {
+				x = forward[idx+1]
+			} else {
+				x = forward[idx-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[idx] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if bIdx := offset + delta - k; bIdx >= 0 && bIdx < size && backward[bIdx] != -1 && x >= n-backward[bIdx] {
+					return x, y
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			idx := offset + k
+			var x int
+			if k == -d || k != d && backward[idx-1] < backward[idx+1] {
+				x = backward[idx+1]
+			} else {
+				x = backward[idx-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[idx] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if fIdx := offset + delta - k; fIdx >= 0 && fIdx < size && forward[fIdx] != -1 {
+					if fx := forward[fIdx]; fx >= n-x {
+						return fx, fx - (delta - k)
+					}
+				}
+			}
+		}
+	}
+	return -1, -1
+}
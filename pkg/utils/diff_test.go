@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\n"
+
+	assert.Empty(t, UnifiedDiff("a", "b", a, a))
+	assert.Equal(t, `--- a
++++ b
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`, UnifiedDiff("a", "b", a, b))
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		l := make([]string, random.Intn(30))
+		for i := range l {
+			l[i] = string(rune('a' + random.Intn(4)))
+		}
+		return l
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		var gotA, gotB []string
+		var edits int
+		for _, l := range diffLines(a, b) {
+			if l.op != '+' {
+				gotA = append(gotA, l.text)
+			}
+			if l.op != '-' {
+				gotB = append(gotB, l.text)
+			}
+			if l.op != ' ' {
+				edits++
+			}
+		}
+		assert.Equal(t, strings.Join(a, ","), strings.Join(gotA, ","))
+		assert.Equal(t, strings.Join(b, ","), strings.Join(gotB, ","))
+		assert.Equal(t, len(a)+len(b)-2*lcsLength(a, b), edits, "a: %v, b: %v", a, b)
+	}
+}
+
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -10,12 +10,15 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/term"
 )
 
 type Host struct {
 	IP     string
 	Port   int
 	Suffix string
+	Vars   map[string]any
 }
 
 func ReadFiltersFromUser(path string) (string, error) {
@@ -96,23 +99,77 @@ func ReadFilesFromUser(path string) ([][]byte, error) {
 	}
 }
 
+// ParseNamespaces parses prefix=uri namespace mappings.
+func ParseNamespaces(mappings []string) (map[string]string, error) {
+	namespaces := make(map[string]string, len(mappings))
+	for _, mapping := range mappings {
+		prefix, uri, found := strings.Cut(mapping, "=")
+		if !found || prefix == "" || uri == "" {
+			return nil, fmt.Errorf("invalid namespace mapping %s, expected prefix=uri", mapping)
+		}
+		namespaces[prefix] = uri
+	}
+	return namespaces, nil
+}
+
+// Confirm asks user confirmation from terminal, non-interactive stdin is never confirmed.
+func Confirm(prompt string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("stdin is not a terminal, cannot ask confirmation")
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
 func TimeStamp() string {
 	ts := time.Now().Format(time.DateOnly)
 	return strings.Replace(ts, "-", "_", -1)
 }
 
+// ParseTime parses RFC3339 time or duration relative to now, e.g. -2h or +30m, empty value returns zero time.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		if d, err := time.ParseDuration(value); err == nil {
+			return now.Add(d), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use RFC3339 or relative duration, e.g. -2h", value)
+}
+
 func createHost(text string) *Host {
-	hostWithFileName := strings.Split(text, " ")
-	if len(hostWithFileName) < 1 {
+	fields := strings.Fields(text)
+	if len(fields) < 1 {
 		return nil
 	}
 
-	var suffix string
-	if len(hostWithFileName) == 2 {
-		suffix = hostWithFileName[1]
+	var (
+		suffix string
+		vars   map[string]any
+	)
+	for _, field := range fields[1:] {
+		if key, value, found := strings.Cut(field, "="); found {
+			if vars == nil {
+				vars = make(map[string]any)
+			}
+			vars[key] = value
+		} else if suffix == "" {
+			suffix = field
+		}
 	}
 
-	hostAndPort := strings.Split(hostWithFileName[0], ":")
+	hostAndPort := strings.Split(fields[0], ":")
 	if len(hostAndPort) == 2 {
 		port, err := strconv.Atoi(hostAndPort[1])
 		if err != nil {
@@ -122,11 +179,13 @@ func createHost(text string) *Host {
 			IP:     hostAndPort[0],
 			Port:   port,
 			Suffix: suffix,
+			Vars:   vars,
 		}
 	}
 	return &Host{
-		IP:     hostWithFileName[0],
+		IP:     fields[0],
 		Suffix: suffix,
+		Vars:   vars,
 	}
 }
 
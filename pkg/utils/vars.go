@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultVarsKey is the vars file key, which values are applied to all devices.
+const DefaultVarsKey = "all"
+
+// ReadVarsFromUser reads per-device template variables from csv or yaml file, keyed by device IP.
+//
+// Csv file must have header row, column named host or ip identifies the device, others are variables.
+// Yaml file is a map of device IP to variables, variables under key "all" are applied to all devices.
+func ReadVarsFromUser(path string) (map[string]map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseCSVVars(string(b))
+	case ".yaml", ".yml":
+		vars := make(map[string]map[string]any)
+		if err := yaml.Unmarshal(b, &vars); err != nil {
+			return nil, fmt.Errorf("failed to parse vars file %s, %v", path, err)
+		}
+		return vars, nil
+	default:
+		return nil, fmt.Errorf("unsupported vars file %s, expected .csv, .yaml or .yml", path)
+	}
+}
+
+func parseCSVVars(input string) (map[string]map[string]any, error) {
+	records, err := csv.NewReader(strings.NewReader(input)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv vars, %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("csv vars file is empty")
+	}
+
+	header := records[0]
+	hostColumn := -1
+	for i, name := range header {
+		if name == "host" || name == "ip" {
+			hostColumn = i
+			break
+		}
+	}
+	if hostColumn < 0 {
+		return nil, fmt.Errorf("csv vars file must have host or ip column in header row")
+	}
+
+	vars := make(map[string]map[string]any, len(records)-1)
+	for _, record := range records[1:] {
+		deviceVars := make(map[string]any, len(header)-1)
+		for i, value := range record {
+			if i != hostColumn {
+				deviceVars[header[i]] = value
+			}
+		}
+		vars[record[hostColumn]] = deviceVars
+	}
+	return vars, nil
+}
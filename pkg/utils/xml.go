@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
 	"strings"
 
 	"github.com/go-xmlfmt/xmlfmt"
@@ -16,3 +20,28 @@ func FormatXML(input string) string {
 
 	return strings.TrimPrefix(xmlfmt.FormatXML(input, "", "  "), "\n")
 }
+
+// ExtractData returns the content of the first data element found in reply.
+// If reply has no data element, it is returned as is.
+func ExtractData(reply string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(reply))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return reply, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "data" {
+			var data struct {
+				Inner []byte `xml:",innerxml"`
+			}
+			if err := decoder.DecodeElement(&data, &start); err != nil {
+				return "", err
+			}
+			return string(bytes.TrimSpace(data.Inner)), nil
+		}
+	}
+}
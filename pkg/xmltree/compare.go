@@ -0,0 +1,97 @@
+package xmltree
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Contains reports whether actual contains all desired nodes, honouring edit-config operation attributes.
+// Nodes with delete or remove operation must be missing from actual, replace operation requires equal subtree.
+// Sibling order is ignored, so list entries and leaf-lists match regardless of position.
+func Contains(actual, desired []*Node) bool {
+	for _, d := range desired {
+		switch d.Operation() {
+		case "delete", "remove":
+			target := d.Clone()
+			target.Attr = withoutOperation(target.Attr)
+			if matchAny(actual, target, contains) {
+				return false
+			}
+		case "replace":
+			if !matchAny(actual, d, equal) {
+				return false
+			}
+		default:
+			if !matchAny(actual, d, contains) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Equal reports whether a and b contain same elements and leaf values, ignoring sibling order and attributes.
+func Equal(a, b []*Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	used := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for i, y := range b {
+			if !used[i] && equal(y, x) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(actual []*Node, desired *Node, match func(actual, desired *Node) bool) bool {
+	for _, a := range actual {
+		if match(a, desired) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(actual, desired *Node) bool {
+	if actual.Name != desired.Name {
+		return false
+	}
+	if desired.IsLeaf() {
+		return desired.Text == "" || actual.IsLeaf() && normalize(actual.Text) == normalize(desired.Text)
+	}
+	return Contains(actual.Children, desired.Children)
+}
+
+func equal(a, b *Node) bool {
+	if a.Name != b.Name {
+		return false
+	}
+	if a.IsLeaf() || b.IsLeaf() {
+		return a.IsLeaf() && b.IsLeaf() && normalize(a.Text) == normalize(b.Text)
+	}
+	return Equal(a.Children, b.Children)
+}
+
+func withoutOperation(attrs []xml.Attr) []xml.Attr {
+	var filtered []xml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Local != "operation" || attr.Name.Space == xmlnsPrefix {
+			filtered = append(filtered, attr)
+		}
+	}
+	return filtered
+}
+
+// normalize collapses whitespace of leaf values.
+func normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
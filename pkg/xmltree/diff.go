@@ -0,0 +1,158 @@
+package xmltree
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "changed"
+)
+
+// Change is single difference between two configs. Old and New hold leaf values,
+// or compact xml of added and removed subtrees.
+type Change struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s %s", c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s %s", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// Diff compares configs semantically. Elements are compared namespace aware, repeated list entries are matched by
+// their keys regardless of position, leaf-list entries by value and leaf values are whitespace normalized.
+func Diff(a, b []*Node) []Change {
+	var changes []Change
+	diffSiblings("", a, b, &changes)
+	return changes
+}
+
+func diffSiblings(path string, a, b []*Node, changes *[]Change) {
+	matched := make([]bool, len(a))
+	for _, y := range b {
+		i := diffMatch(a, b, matched, y)
+		if i < 0 {
+			*changes = append(*changes, Change{Kind: ChangeAdded, Path: path + "/" + segment(a, b, y), New: value(y)})
+			continue
+		}
+		matched[i] = true
+
+		x := a[i]
+		p := path + "/" + segment(a, b, y)
+		switch {
+		case x.IsLeaf() && y.IsLeaf():
+			if normalize(x.Text) != normalize(y.Text) {
+				*changes = append(*changes, Change{Kind: ChangeModified, Path: p, Old: x.Text, New: y.Text})
+			}
+		case x.IsLeaf() || y.IsLeaf():
+			*changes = append(*changes, Change{Kind: ChangeModified, Path: p, Old: value(x), New: value(y)})
+		default:
+			diffSiblings(p, x.Children, y.Children, changes)
+		}
+	}
+
+	for i, x := range a {
+		if !matched[i] {
+			*changes = append(*changes, Change{Kind: ChangeRemoved, Path: path + "/" + segment(a, b, x), Old: value(x)})
+		}
+	}
+}
+
+// diffMatch finds sibling from a matching node of b. Repeated siblings without keys are matched by position.
+func diffMatch(a, b []*Node, matched []bool, y *Node) int {
+	if i, ambiguous := findMatch(a, b, matched, y); !ambiguous {
+		return i
+	}
+
+	var position int
+	for _, node := range b {
+		if node == y {
+			break
+		}
+		if node.Name == y.Name {
+			position++
+		}
+	}
+	for i, x := range a {
+		if x.Name != y.Name {
+			continue
+		}
+		if position == 0 {
+			if matched[i] {
+				return -1
+			}
+			return i
+		}
+		position--
+	}
+	return -1
+}
+
+// Segment returns path segment of node, list entries include their keys, e.g. interface[name=eth0].
+func Segment(node *Node) string {
+	keys := node.Keys()
+	if len(keys) == 0 {
+		return node.Name.Local
+	}
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, fmt.Sprintf("%s=%s", key.Name.Local, key.Text))
+	}
+	return fmt.Sprintf("%s[%s]", node.Name.Local, strings.Join(values, ","))
+}
+
+// segment returns path segment of node in diff, keys are included only for repeated siblings, which are list entries.
+func segment(a, b []*Node, node *Node) string {
+	if repeated(a, b, node.Name) {
+		return Segment(node)
+	}
+	return node.Name.Local
+}
+
+func value(node *Node) string {
+	if node.IsLeaf() {
+		return node.Text
+	}
+	return Marshal([]*Node{node})
+}
+
+// Canonicalize sorts siblings by namespace, name and list keys, so equal configs serialize equally.
+// Key leaves are sorted first and repeated siblings without keys keep their order.
+func Canonicalize(nodes []*Node) {
+	slices.SortStableFunc(nodes, func(x, y *Node) int {
+		if c := cmp.Compare(key(y), key(x)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(x.Name.Space, y.Name.Space); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(x.Name.Local, y.Name.Local); c != 0 {
+			return c
+		}
+		return cmp.Compare(Segment(x), Segment(y))
+	})
+	for _, node := range nodes {
+		Canonicalize(node.Children)
+	}
+}
+
+func key(node *Node) int {
+	if node.IsLeaf() && IsKey(node.Name.Local) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package xmltree
+
+import "strings"
+
+// IsKey reports whether leaf name looks like list key, e.g. name, id, index or interface-name.
+// Without yang schema this is heuristic, wrong guess only makes subtree filter less selective.
+func IsKey(local string) bool {
+	switch local {
+	case "name", "id", "index", "key":
+		return true
+	}
+	for _, suffix := range []string{"-name", "-id", "-index", "-key"} {
+		if strings.HasSuffix(local, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns key leaf children of node.
+func (n *Node) Keys() []*Node {
+	var keys []*Node
+	for _, child := range n.Children {
+		if child.IsLeaf() && child.Text != "" && IsKey(child.Name.Local) {
+			keys = append(keys, child)
+		}
+	}
+	return keys
+}
+
+// SubtreeFilter derives subtree filter selecting all nodes touched by edit-config payload.
+// Key leaves are kept as content match nodes, other leaves become selection nodes
+// and operation attributes are dropped.
+func SubtreeFilter(nodes []*Node) []*Node {
+	filter := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		filter = append(filter, subtreeFilter(node, nil))
+	}
+	return filter
+}
+
+func subtreeFilter(node, parent *Node) *Node {
+	f := &Node{
+		Name:   node.Name,
+		Attr:   withoutOperation(node.Attr),
+		Parent: parent,
+	}
+	if node.IsLeaf() {
+		if parent != nil && IsKey(node.Name.Local) {
+			f.Text = node.Text
+		}
+		return f
+	}
+
+	for _, child := range node.Children {
+		f.Children = append(f.Children, subtreeFilter(child, f))
+	}
+	return f
+}
+
+// RootFilter derives subtree filter selecting whole subtrees of payload root nodes.
+func RootFilter(nodes []*Node) []*Node {
+	filter := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		filter = append(filter, &Node{Name: node.Name, Attr: withoutOperation(node.Attr)})
+	}
+	return filter
+}
@@ -0,0 +1,147 @@
+package xmltree
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// PathFilter builds subtree filter from paths like /sros:state/port[port-id=1/1/1]/ethernet/lldp.
+// Prefixes are resolved with namespaces mapping, segments without prefix inherit namespace of parent.
+// Key predicates become content match nodes and last segment of path selects whole subtree.
+func PathFilter(paths []string, namespaces map[string]string) (string, error) {
+	var (
+		roots    []*Node
+		selected = make(map[*Node]bool)
+		keyCount = make(map[*Node]int)
+	)
+	for _, path := range paths {
+		segments, err := splitPath(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid path %s, %v", path, err)
+		}
+
+		var (
+			parent *Node
+			space  string
+		)
+		for i, segment := range segments {
+			name, keys, err := parseSegment(segment)
+			if err != nil {
+				return "", fmt.Errorf("invalid path %s, %v", path, err)
+			}
+			if prefix, local, found := strings.Cut(name, ":"); found {
+				uri, ok := namespaces[prefix]
+				if !ok {
+					return "", fmt.Errorf("invalid path %s, unknown namespace prefix %s", path, prefix)
+				}
+				name, space = local, uri
+			}
+
+			siblings := &roots
+			if parent != nil {
+				siblings = &parent.Children
+			}
+			node := findSegment(*siblings, xml.Name{Space: space, Local: name}, keys, keyCount)
+			if node == nil {
+				node = &Node{Name: xml.Name{Space: space, Local: name}, Parent: parent}
+				for _, key := range keys {
+					node.Children = append(node.Children, &Node{Name: xml.Name{Space: space, Local: key[0]}, Text: key[1], Parent: node})
+				}
+				keyCount[node] = len(keys)
+				*siblings = append(*siblings, node)
+			}
+
+			if i == len(segments)-1 {
+				// selecting whole subtree overrides narrower selections
+				selected[node] = true
+				node.Children = node.Children[:len(keys)]
+			}
+			if selected[node] {
+				break
+			}
+			parent = node
+		}
+	}
+	return Marshal(roots), nil
+}
+
+// splitPath splits path by slashes, which are not inside key predicates.
+func splitPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path must be absolute")
+	}
+
+	var (
+		segments []string
+		depth    int
+		start    = 1
+	)
+	for i := 1; i < len(path); i++ {
+		switch path[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				segments = append(segments, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced brackets")
+	}
+	segments = append(segments, path[start:])
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("empty path segment")
+		}
+	}
+	return segments, nil
+}
+
+// parseSegment parses name[key=value][key2='value'] segment to name and key value pairs.
+func parseSegment(segment string) (string, [][2]string, error) {
+	name, predicates, _ := strings.Cut(segment, "[")
+	if name == "" {
+		return "", nil, fmt.Errorf("missing name in segment %s", segment)
+	}
+	if predicates == "" {
+		return name, nil, nil
+	}
+
+	var keys [][2]string
+	for _, predicate := range strings.Split(strings.TrimSuffix(predicates, "]"), "][") {
+		key, value, found := strings.Cut(predicate, "=")
+		if !found || key == "" {
+			return "", nil, fmt.Errorf("invalid key predicate [%s]", predicate)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		keys = append(keys, [2]string{strings.TrimSpace(key), value})
+	}
+	return name, keys, nil
+}
+
+// findSegment finds sibling with name and exactly same key predicates, which are stored as first children.
+func findSegment(nodes []*Node, name xml.Name, keys [][2]string, keyCount map[*Node]int) *Node {
+	for _, node := range nodes {
+		if node.Name != name || keyCount[node] != len(keys) {
+			continue
+		}
+		match := true
+		for i, key := range keys {
+			if child := node.Children[i]; child.Name.Local != key[0] || child.Text != key[1] {
+				match = false
+			}
+		}
+		if match {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package xmltree
+
+import "encoding/xml"
+
+const (
+	OperationMerge   = "merge"
+	OperationReplace = "replace"
+	OperationCreate  = "create"
+	OperationDelete  = "delete"
+)
+
+// Plan computes minimal edit-config content changing actual config to desired config.
+// Differing nodes are annotated with merge, replace, create or delete operation attributes,
+// repeated siblings are list entries matched by key leaves, see IsKey. Siblings, which can not be told apart
+// without keys, are replaced as whole. Actual nodes missing from desired config are deleted only with prune,
+// otherwise desired config is partial and only merged.
+func Plan(actual, desired []*Node, prune bool) []*Node {
+	edits, ambiguous := planSiblings(actual, desired, prune)
+	if ambiguous {
+		edits = edits[:0]
+		for _, d := range desired {
+			edits = append(edits, withOperation(d.Clone(), OperationReplace))
+		}
+	}
+	return edits
+}
+
+// PlanDocument wraps plan edits to config element, which can be used as edit-config payload.
+func PlanDocument(edits []*Node) []*Node {
+	root := &Node{
+		Name: xml.Name{Space: BaseNamespace, Local: "config"},
+		Attr: []xml.Attr{{Name: xml.Name{Space: xmlnsPrefix, Local: "nc"}, Value: BaseNamespace}},
+	}
+	for _, edit := range edits {
+		edit.Parent = root
+		root.Children = append(root.Children, edit)
+	}
+	return []*Node{root}
+}
+
+func planSiblings(actual, desired []*Node, prune bool) ([]*Node, bool) {
+	var (
+		edits   []*Node
+		matched = make([]bool, len(actual))
+	)
+	for _, d := range desired {
+		i, ambiguous := findMatch(actual, desired, matched, d)
+		if ambiguous {
+			return nil, true
+		}
+		if i < 0 {
+			edits = append(edits, withOperation(d.Clone(), OperationCreate))
+			continue
+		}
+		matched[i] = true
+
+		a := actual[i]
+		if d.IsLeaf() || a.IsLeaf() {
+			if !d.IsLeaf() || !a.IsLeaf() {
+				edits = append(edits, withOperation(d.Clone(), OperationReplace))
+			} else if normalize(a.Text) != normalize(d.Text) {
+				edits = append(edits, withOperation(d.Clone(), OperationMerge))
+			}
+			continue
+		}
+
+		childEdits, ambiguous := planSiblings(a.Children, d.Children, prune)
+		switch {
+		case ambiguous:
+			edits = append(edits, withOperation(d.Clone(), OperationReplace))
+		case len(childEdits) > 0:
+			edits = append(edits, entry(d, childEdits))
+		}
+	}
+
+	for i, a := range actual {
+		if prune && !matched[i] {
+			edits = append(edits, withOperation(deleteTarget(a), OperationDelete))
+		}
+	}
+	return edits, false
+}
+
+// findMatch returns index of actual sibling matching desired node, or -1 if not found.
+// Only repeated siblings are list entries, which are matched by keys, single elements are matched by name.
+// Ambiguous is reported for repeated siblings without keys.
+func findMatch(actual, desired []*Node, matched []bool, d *Node) (int, bool) {
+	list := repeated(actual, desired, d.Name)
+	if d.IsLeaf() {
+		for i, a := range actual {
+			if !matched[i] && a.Name == d.Name && (!list || normalize(a.Text) == normalize(d.Text)) {
+				return i, false
+			}
+		}
+		return -1, false
+	}
+
+	keys := d.Keys()
+	if list && len(keys) == 0 {
+		return -1, true
+	}
+	for i, a := range actual {
+		if matched[i] || a.Name != d.Name {
+			continue
+		}
+		if !list || hasKeys(a, keys) {
+			return i, false
+		}
+	}
+	return -1, false
+}
+
+// repeated reports whether name repeats in either sibling list, which makes them list or leaf-list entries.
+func repeated(actual, desired []*Node, name xml.Name) bool {
+	return count(actual, name) > 1 || count(desired, name) > 1
+}
+
+func hasKeys(node *Node, keys []*Node) bool {
+	for _, key := range keys {
+		child := node.Child(key.Name.Local)
+		if child == nil || normalize(child.Text) != normalize(key.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+func count(nodes []*Node, name xml.Name) int {
+	var n int
+	for _, node := range nodes {
+		if node.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// entry builds container holding child edits, key leaves are included to identify list entry.
+func entry(d *Node, edits []*Node) *Node {
+	e := &Node{Name: d.Name, Attr: declarations(d.Attr)}
+	for _, key := range d.Keys() {
+		if count(edits, key.Name) == 0 {
+			k := key.Clone()
+			k.Parent = e
+			e.Children = append(e.Children, k)
+		}
+	}
+	for _, edit := range edits {
+		edit.Parent = e
+		e.Children = append(e.Children, edit)
+	}
+	return e
+}
+
+// deleteTarget builds node identifying actual node for delete operation.
+func deleteTarget(a *Node) *Node {
+	target := &Node{Name: a.Name, Attr: declarations(a.Attr), Text: a.Text}
+	for _, key := range a.Keys() {
+		k := key.Clone()
+		k.Parent = target
+		target.Children = append(target.Children, k)
+	}
+	return target
+}
+
+func withOperation(node *Node, operation string) *Node {
+	node.Attr = append(withoutOperation(node.Attr), xml.Attr{
+		Name:  xml.Name{Space: BaseNamespace, Local: "operation"},
+		Value: operation,
+	})
+	return node
+}
+
+func declarations(attrs []xml.Attr) []xml.Attr {
+	var decls []xml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Space == xmlnsPrefix {
+			decls = append(decls, attr)
+		}
+	}
+	return decls
+}
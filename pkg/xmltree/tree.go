@@ -0,0 +1,291 @@
+package xmltree
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	xmlnsPrefix = "xmlns"
+
+	// BaseNamespace is netconf base namespace, used by operation attribute.
+	BaseNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+)
+
+// Node is namespace aware xml element. Text is trimmed character data of leaf elements.
+type Node struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Text     string
+	Children []*Node
+	Parent   *Node
+}
+
+// Parse parses xml document or fragment with multiple root elements.
+func Parse(data []byte) ([]*Node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		roots []*Node
+		stack []*Node
+		text  strings.Builder
+	)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xml, %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &Node{Name: t.Name, Attr: t.Copy().Attr}
+			if len(stack) > 0 {
+				node.Parent = stack[len(stack)-1]
+				node.Parent.Children = append(node.Parent.Children, node)
+			} else {
+				roots = append(roots, node)
+			}
+			stack = append(stack, node)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			if len(node.Children) == 0 {
+				node.Text = strings.TrimSpace(text.String())
+			}
+			stack = stack[:len(stack)-1]
+			text.Reset()
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("failed to parse xml, unclosed element %s", stack[len(stack)-1].Name.Local)
+	}
+	return roots, nil
+}
+
+// ParseReply parses rpc-reply and returns content of data element.
+// Replies without rpc-reply or data element are returned as parsed.
+func ParseReply(data string) ([]*Node, error) {
+	nodes, err := Parse([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 1 && nodes[0].Name.Local == "rpc-reply" {
+		nodes = nodes[0].Children
+	}
+	if len(nodes) == 1 && nodes[0].Name.Local == "data" {
+		nodes = nodes[0].Children
+	}
+	return detach(nodes), nil
+}
+
+// Unwrap returns children of single root element named local, e.g. config element of edit-config payload.
+func Unwrap(nodes []*Node, local string) []*Node {
+	if len(nodes) == 1 && nodes[0].Name.Local == local {
+		return detach(nodes[0].Children)
+	}
+	return nodes
+}
+
+func detach(nodes []*Node) []*Node {
+	for _, node := range nodes {
+		node.Parent = nil
+	}
+	return nodes
+}
+
+// IsLeaf reports whether node has no child elements.
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// Attribute returns value of attribute by local name, namespace declarations are ignored.
+func (n *Node) Attribute(local string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Name.Local == local && attr.Name.Space != xmlnsPrefix {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Operation returns edit-config operation attribute of node, or empty string.
+func (n *Node) Operation() string {
+	operation, _ := n.Attribute("operation")
+	return operation
+}
+
+// Child returns first child element with local name.
+func (n *Node) Child(local string) *Node {
+	for _, child := range n.Children {
+		if child.Name.Local == local {
+			return child
+		}
+	}
+	return nil
+}
+
+// Path returns slash separated path of local names from root to node.
+func (n *Node) Path() string {
+	var names []string
+	for node := n; node != nil; node = node.Parent {
+		names = append(names, node.Name.Local)
+	}
+	var b strings.Builder
+	for i := len(names) - 1; i >= 0; i-- {
+		b.WriteString("/")
+		b.WriteString(names[i])
+	}
+	return b.String()
+}
+
+// Clone returns deep copy of node without parent.
+func (n *Node) Clone() *Node {
+	clone := &Node{
+		Name: n.Name,
+		Attr: append([]xml.Attr(nil), n.Attr...),
+		Text: n.Text,
+	}
+	for _, child := range n.Children {
+		c := child.Clone()
+		c.Parent = clone
+		clone.Children = append(clone.Children, c)
+	}
+	return clone
+}
+
+// Find returns first node or descendant with local name, in document order.
+func Find(nodes []*Node, local string) *Node {
+	for _, node := range nodes {
+		if node.Name.Local == local {
+			return node
+		}
+		if found := Find(node.Children, local); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Strip removes elements and attributes with local names, from nodes and their descendants.
+func Strip(nodes []*Node, names []string) []*Node {
+	strip := make(map[string]bool, len(names))
+	for _, name := range names {
+		strip[name] = true
+	}
+	return stripNodes(nodes, strip)
+}
+
+func stripNodes(nodes []*Node, strip map[string]bool) []*Node {
+	kept := nodes[:0]
+	for _, node := range nodes {
+		if strip[node.Name.Local] {
+			continue
+		}
+		attrs := node.Attr[:0]
+		for _, attr := range node.Attr {
+			if attr.Name.Space == xmlnsPrefix || !strip[attr.Name.Local] {
+				attrs = append(attrs, attr)
+			}
+		}
+		node.Attr = attrs
+		node.Children = stripNodes(node.Children, strip)
+		kept = append(kept, node)
+	}
+	return kept
+}
+
+// Walk calls f for node and all its descendants, in document order.
+func Walk(nodes []*Node, f func(node *Node)) {
+	for _, node := range nodes {
+		f(node)
+		Walk(node.Children, f)
+	}
+}
+
+// Marshal serializes nodes back to xml. Default namespace is declared on every element, which namespace differs from its parent.
+// Prefixed namespace declarations of detached ancestors are repeated on root elements.
+func Marshal(nodes []*Node) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		scope := make(map[string]string)
+		var inherited []xml.Attr
+		for parent := node.Parent; parent != nil; parent = parent.Parent {
+			for _, attr := range parent.Attr {
+				if attr.Name.Space == xmlnsPrefix {
+					if _, found := scope[attr.Value]; !found {
+						scope[attr.Value] = attr.Name.Local
+						inherited = append(inherited, attr)
+					}
+				}
+			}
+		}
+		marshal(&b, node, "", scope, inherited)
+	}
+	return b.String()
+}
+
+func marshal(b *strings.Builder, node *Node, parentSpace string, parentScope map[string]string, inherited []xml.Attr) {
+	b.WriteString("<")
+	b.WriteString(node.Name.Local)
+	if node.Name.Space != parentSpace {
+		writeAttr(b, xmlnsPrefix, node.Name.Space)
+	}
+
+	scope := make(map[string]string, len(parentScope))
+	for space, prefix := range parentScope {
+		scope[space] = prefix
+	}
+	for _, attr := range append(inherited, node.Attr...) {
+		if attr.Name.Space == xmlnsPrefix {
+			scope[attr.Value] = attr.Name.Local
+			writeAttr(b, xmlnsPrefix+":"+attr.Name.Local, attr.Value)
+		}
+	}
+	for _, attr := range node.Attr {
+		switch {
+		case attr.Name.Space == xmlnsPrefix || (attr.Name.Space == "" && attr.Name.Local == xmlnsPrefix):
+		case attr.Name.Space == "":
+			writeAttr(b, attr.Name.Local, attr.Value)
+		default:
+			prefix, found := scope[attr.Name.Space]
+			if !found {
+				prefix = fmt.Sprintf("ns%d", len(scope))
+				scope[attr.Name.Space] = prefix
+				writeAttr(b, xmlnsPrefix+":"+prefix, attr.Name.Space)
+			}
+			writeAttr(b, prefix+":"+attr.Name.Local, attr.Value)
+		}
+	}
+
+	if node.IsLeaf() && node.Text == "" {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">")
+	if node.IsLeaf() {
+		_ = xml.EscapeText(b, []byte(node.Text))
+	}
+	for _, child := range node.Children {
+		marshal(b, child, node.Name.Space, scope, nil)
+	}
+	b.WriteString("</")
+	b.WriteString(node.Name.Local)
+	b.WriteString(">")
+}
+
+func writeAttr(b *strings.Builder, name, value string) {
+	b.WriteString(" ")
+	b.WriteString(name)
+	b.WriteString(`="`)
+	_ = xml.EscapeText(b, []byte(value))
+	b.WriteString(`"`)
+}
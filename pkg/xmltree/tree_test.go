@@ -0,0 +1,226 @@
+package xmltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const running = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><data>
+<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf">
+    <system>
+        <grpc><admin-state>enable</admin-state></grpc>
+        <security><tls>
+            <cert-profile><cert-profile-name>other</cert-profile-name></cert-profile>
+            <cert-profile><cert-profile-name>grpc-tls-certs</cert-profile-name><admin-state>enable</admin-state></cert-profile>
+        </tls></security>
+    </system>
+</configure>
+</data></rpc-reply>`
+
+func mustParse(t *testing.T, data string) []*Node {
+	t.Helper()
+	nodes, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nodes
+}
+
+func TestMarshal(t *testing.T) {
+	input := `<configure xmlns="urn:a"><port><port-id>1/1/1</port-id><type xmlns:t="urn:t">t:eth</type><desc xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="remove"/></port><other xmlns="urn:b">x &amp; y</other></configure>`
+
+	nodes := mustParse(t, input)
+	assert.Equal(t, input, Marshal(nodes))
+	assert.Equal(t, "remove", nodes[0].Children[0].Children[2].Operation())
+	assert.Equal(t, "/configure/port/port-id", nodes[0].Children[0].Children[0].Path())
+}
+
+func TestSubtreeFilter(t *testing.T) {
+	payload := mustParse(t, `<config><configure xmlns="urn:a"><system><grpc><admin-state>disable</admin-state></grpc>
+<cert-profile operation="remove"><cert-profile-name>grpc-tls-certs</cert-profile-name></cert-profile></system></configure></config>`)
+
+	filter := SubtreeFilter(Unwrap(payload, "config"))
+	assert.Equal(t,
+		`<configure xmlns="urn:a"><system><grpc><admin-state/></grpc><cert-profile><cert-profile-name>grpc-tls-certs</cert-profile-name></cert-profile></system></configure>`,
+		Marshal(filter),
+	)
+}
+
+func TestContains(t *testing.T) {
+	actual, err := ParseReply(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected bool
+	}{
+		{
+			name:     "leaf already set",
+			payload:  `<config><configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system><grpc><admin-state>enable</admin-state></grpc></system></configure></config>`,
+			expected: true,
+		},
+		{
+			name:     "leaf differs",
+			payload:  `<config><configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system><grpc><admin-state>disable</admin-state></grpc></system></configure></config>`,
+			expected: false,
+		},
+		{
+			name:     "list entry in different position",
+			payload:  `<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system><security><tls><cert-profile><cert-profile-name>grpc-tls-certs</cert-profile-name><admin-state>enable</admin-state></cert-profile></tls></security></system></configure>`,
+			expected: true,
+		},
+		{
+			name:     "removed list entry still exists",
+			payload:  `<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system><security><tls><cert-profile operation="remove"><cert-profile-name>grpc-tls-certs</cert-profile-name></cert-profile></tls></security></system></configure>`,
+			expected: false,
+		},
+		{
+			name:     "removed list entry already missing",
+			payload:  `<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system><security><tls><cert-profile operation="remove"><cert-profile-name>missing</cert-profile-name></cert-profile></tls></security></system></configure>`,
+			expected: true,
+		},
+		{
+			name:     "different namespace",
+			payload:  `<configure xmlns="urn:other"><system><grpc><admin-state>enable</admin-state></grpc></system></configure>`,
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desired := Unwrap(mustParse(t, test.payload), "config")
+			assert.Equal(t, test.expected, Contains(actual, desired))
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	actual, err := ParseReply(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desired := mustParse(t, `<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf">
+    <system>
+        <grpc><admin-state>disable</admin-state></grpc>
+        <security><tls>
+            <cert-profile><cert-profile-name>grpc-tls-certs</cert-profile-name><admin-state>enable</admin-state></cert-profile>
+            <cert-profile><cert-profile-name>new</cert-profile-name></cert-profile>
+        </tls></security>
+    </system>
+</configure>`)
+
+	expected := `<config xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">` +
+		`<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system>` +
+		`<grpc><admin-state nc:operation="merge">disable</admin-state></grpc>` +
+		`<security><tls>` +
+		`<cert-profile nc:operation="create"><cert-profile-name>new</cert-profile-name></cert-profile>` +
+		`<cert-profile nc:operation="delete"><cert-profile-name>other</cert-profile-name></cert-profile>` +
+		`</tls></security></system></configure></config>`
+	assert.Equal(t, expected, Marshal(PlanDocument(Plan(actual, desired, true))))
+	assert.Empty(t, Plan(actual, actual, true))
+
+	// without prune, nodes missing from desired config are kept
+	expected = `<config xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">` +
+		`<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><system>` +
+		`<grpc><admin-state nc:operation="merge">disable</admin-state></grpc>` +
+		`<security><tls>` +
+		`<cert-profile nc:operation="create"><cert-profile-name>new</cert-profile-name></cert-profile>` +
+		`</tls></security></system></configure></config>`
+	assert.Equal(t, expected, Marshal(PlanDocument(Plan(actual, desired, false))))
+
+	// key-like leaf of singleton container is changed in place
+	actual = mustParse(t, `<system xmlns="urn:ietf:params:xml:ns:yang:ietf-system"><name>old</name><location>lab</location></system>`)
+	desired = mustParse(t, `<system xmlns="urn:ietf:params:xml:ns:yang:ietf-system"><location>lab</location><name>new</name></system>`)
+	assert.Equal(t,
+		`<config xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">`+
+			`<system xmlns="urn:ietf:params:xml:ns:yang:ietf-system"><name nc:operation="merge">new</name></system></config>`,
+		Marshal(PlanDocument(Plan(actual, desired, true))),
+	)
+}
+
+func TestDiff(t *testing.T) {
+	a := mustParse(t, `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth0</name><mtu>1500</mtu></interface>
+    <interface><name>eth1</name><mtu>1500</mtu></interface>
+</interfaces>`)
+	b := mustParse(t, `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth2</name></interface>
+    <interface><mtu> 9000 </mtu><name>eth1</name></interface>
+</interfaces>`)
+
+	expected := []Change{
+		{Kind: ChangeAdded, Path: "/interfaces/interface[name=eth2]", New: `<interface xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><name>eth2</name></interface>`},
+		{Kind: ChangeModified, Path: "/interfaces/interface[name=eth1]/mtu", Old: "1500", New: "9000"},
+		{Kind: ChangeRemoved, Path: "/interfaces/interface[name=eth0]", Old: `<interface xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><name>eth0</name><mtu>1500</mtu></interface>`},
+	}
+	assert.Equal(t, expected, Diff(a, b))
+	assert.Empty(t, Diff(a, a))
+
+	// key-like leaf of singleton container is changed in place
+	a = mustParse(t, `<system xmlns="urn:ietf:params:xml:ns:yang:ietf-system"><name>old</name><location>lab</location></system>`)
+	b = mustParse(t, `<system xmlns="urn:ietf:params:xml:ns:yang:ietf-system"><name>new</name><location>lab</location></system>`)
+	assert.Equal(t, []Change{{Kind: ChangeModified, Path: "/system/name", Old: "old", New: "new"}}, Diff(a, b))
+}
+
+func TestXPath(t *testing.T) {
+	nodes := mustParse(t, `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+    <interface><name>eth0</name><description>uplink</description><enabled>true</enabled><mtu>9000</mtu></interface>
+    <interface><name>eth1</name><enabled>false</enabled><mtu>1500</mtu></interface>
+    <interface><name>lo0</name><enabled>true</enabled></interface>
+</interfaces>`)
+	namespaces := map[string]string{"if": "urn:ietf:params:xml:ns:yang:ietf-interfaces"}
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: "/interfaces/interface[enabled='false']/name", expected: "eth1"},
+		{expr: "/if:interfaces/if:interface[not(if:description)][1]/if:name", expected: "eth1"},
+		{expr: "//interface[mtu > 2000]/name", expected: "eth0"},
+		{expr: "//interface[last()]/name", expected: "lo0"},
+		{expr: "//name[starts-with(., 'lo')]", expected: "lo0"},
+		{expr: "//description/../name", expected: "eth0"},
+		{expr: "count(//interface[enabled='true'])", expected: "2"},
+		{expr: "count(/interfaces/interface) = 3 and //mtu = 1500", expected: "true"},
+		{expr: "//interface[name='eth9']", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			xpath, err := CompileXPath(test.expr, namespaces)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, XPathString(xpath.Evaluate(nodes)))
+		})
+	}
+
+	_, err := CompileXPath("/foo:interfaces", namespaces)
+	assert.Error(t, err)
+}
+
+func TestPathFilter(t *testing.T) {
+	namespaces := map[string]string{
+		"sros": "urn:nokia.com:sros:ns:yang:sr:state",
+		"conf": "urn:nokia.com:sros:ns:yang:sr:conf",
+	}
+	paths := []string{
+		"/sros:state/port[port-id=1/1/1]/ethernet/lldp",
+		"/sros:state/port[port-id='1/1/1']/oper-state",
+		"/sros:state/port[port-id=1/1/2]",
+		"/conf:configure/service/vprn/interface",
+		"/conf:configure/service/vprn",
+	}
+	expected := `<state xmlns="urn:nokia.com:sros:ns:yang:sr:state">` +
+		`<port><port-id>1/1/1</port-id><ethernet><lldp/></ethernet><oper-state/></port>` +
+		`<port><port-id>1/1/2</port-id></port></state>` +
+		`<configure xmlns="urn:nokia.com:sros:ns:yang:sr:conf"><service><vprn/></service></configure>`
+
+	filter, err := PathFilter(paths, namespaces)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, filter)
+
+	_, err = PathFilter([]string{"/unknown:state"}, namespaces)
+	assert.Error(t, err)
+}
@@ -0,0 +1,632 @@
+package xmltree
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// XPath is compiled expression of XPath 1.0 subset, evaluated client-side against nodes.
+//
+// Supported are absolute and relative location paths with child (/), descendant (//), self (.)
+// and parent (..) steps, name tests with optional prefix and wildcard (*), text(), predicates
+// with positions, comparisons (= != < <= > >=), and, or, parentheses and functions not, count,
+// contains, starts-with, string-length, normalize-space, position and last.
+// Names without prefix match any namespace, prefixed names are resolved with namespace mapping.
+type XPath struct {
+	expr string
+	eval expr
+}
+
+type (
+	expr         func(c xpathContext) any
+	xpathContext struct {
+		node      *Node
+		root      *Node
+		pos, size int
+	}
+)
+
+const (
+	axisChild = iota
+	axisDescendant
+	axisSelf
+	axisParent
+)
+
+// CompileXPath parses expression, prefixes are resolved with namespaces mapping of prefix to uri.
+func CompileXPath(expression string, namespaces map[string]string) (*XPath, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath %q, %v", expression, err)
+	}
+	p := &xpathParser{tokens: tokens, namespaces: namespaces}
+	e, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath %q, %v", expression, err)
+	}
+	return &XPath{expr: expression, eval: e}, nil
+}
+
+func (x *XPath) String() string {
+	return x.expr
+}
+
+// Evaluate evaluates expression against top-level nodes. Result is node-set ([]*Node), string, float64 or bool.
+func (x *XPath) Evaluate(nodes []*Node) any {
+	root := &Node{Children: nodes}
+	return x.eval(xpathContext{node: root, root: root, pos: 1, size: 1})
+}
+
+// EvaluateFrom evaluates expression with node as context node, relative paths start from node.
+// Node must be one of top-level nodes or their descendant.
+func (x *XPath) EvaluateFrom(node *Node, nodes []*Node) any {
+	root := &Node{Children: nodes}
+	return x.eval(xpathContext{node: node, root: root, pos: 1, size: 1})
+}
+
+// Select evaluates expression, returning selected nodes, or error if expression is not location path.
+func (x *XPath) Select(nodes []*Node) ([]*Node, error) {
+	result, ok := x.Evaluate(nodes).([]*Node)
+	if !ok {
+		return nil, fmt.Errorf("xpath %q does not select nodes", x.expr)
+	}
+	return result, nil
+}
+
+// XPathString converts evaluation result to string, node-set is converted to text of first node.
+func XPathString(v any) string {
+	switch v := v.(type) {
+	case []*Node:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].Text
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// XPathBool converts evaluation result to boolean, non-empty node-sets and strings are true.
+func XPathBool(v any) bool {
+	switch v := v.(type) {
+	case []*Node:
+		return len(v) > 0
+	case string:
+		return v != ""
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case bool:
+		return v
+	}
+	return false
+}
+
+func xpathNumber(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(XPathString(v)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+const (
+	tokenEOF = iota
+	tokenName
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.HasPrefix(s[i:], "//"), strings.HasPrefix(s[i:], ".."),
+			strings.HasPrefix(s[i:], "!="), strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="):
+			tokens = append(tokens, token{tokenSymbol, s[i : i+2]})
+			i += 2
+		case strings.ContainsRune("/[]()=<>,.*|", rune(c)):
+			tokens = append(tokens, token{tokenSymbol, string(c)})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string literal")
+			}
+			tokens = append(tokens, token{tokenString, s[i+1 : i+1+end]})
+			i += end + 2
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, s[i:j]})
+			i = j
+		case isNameChar(rune(c), true):
+			j := i
+			for j < len(s) && isNameChar(rune(s[j]), false) {
+				j++
+			}
+			// prefixed name or prefix:*
+			if j+1 < len(s) && s[j] == ':' && (isNameChar(rune(s[j+1]), true) || s[j+1] == '*') {
+				j++
+				if s[j] == '*' {
+					j++
+				} else {
+					for j < len(s) && isNameChar(rune(s[j]), false) {
+						j++
+					}
+				}
+			}
+			tokens = append(tokens, token{tokenName, s[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+func isNameChar(r rune, first bool) bool {
+	if unicode.IsLetter(r) || r == '_' {
+		return true
+	}
+	return !first && (unicode.IsDigit(r) || r == '-' || r == '.')
+}
+
+type xpathParser struct {
+	tokens     []token
+	pos        int
+	namespaces map[string]string
+}
+
+func (p *xpathParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *xpathParser) symbol(s string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) expect(s string) error {
+	if !p.symbol(s) {
+		return fmt.Errorf("expected %q, found %q", s, p.peek().text)
+	}
+	return nil
+}
+
+func (p *xpathParser) keyword(s string) bool {
+	if t := p.peek(); t.kind == tokenName && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c xpathContext) any { return XPathBool(l(c)) || XPathBool(right(c)) }
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseAnd() (expr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c xpathContext) any { return XPathBool(l(c)) && XPathBool(right(c)) }
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseComparison() (expr, error) {
+	left, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "!=", "<=", ">=", "<", ">"} {
+		if p.symbol(op) {
+			right, err := p.parseUnion()
+			if err != nil {
+				return nil, err
+			}
+			return func(c xpathContext) any { return compare(op, left(c), right(c)) }, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseUnion() (expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.symbol("|") {
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c xpathContext) any {
+			a, _ := l(c).([]*Node)
+			b, _ := right(c).([]*Node)
+			return union(a, b)
+		}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parsePrimary() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		p.next()
+		return func(xpathContext) any { return t.text }, nil
+	case t.kind == tokenNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return func(xpathContext) any { return f }, nil
+	case t.kind == tokenSymbol && t.text == "(":
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case t.kind == tokenName && t.text != "text" && p.tokens[p.pos+1].text == "(":
+		return p.parseFunction()
+	}
+	return p.parsePath()
+}
+
+func (p *xpathParser) parseFunction() (expr, error) {
+	name := p.next().text
+	p.next()
+
+	var args []expr
+	for !p.symbol(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	arity := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("function %s expects %d arguments, got %d", name, n, len(args))
+		}
+		return nil
+	}
+	// string functions default to context node, when argument is omitted
+	stringArg := func(c xpathContext) string {
+		if len(args) == 0 {
+			return c.node.Text
+		}
+		return XPathString(args[0](c))
+	}
+
+	switch name {
+	case "not":
+		return func(c xpathContext) any { return !XPathBool(args[0](c)) }, arity(1)
+	case "count":
+		return func(c xpathContext) any {
+			nodes, _ := args[0](c).([]*Node)
+			return float64(len(nodes))
+		}, arity(1)
+	case "contains":
+		return func(c xpathContext) any {
+			return strings.Contains(XPathString(args[0](c)), XPathString(args[1](c)))
+		}, arity(2)
+	case "starts-with":
+		return func(c xpathContext) any {
+			return strings.HasPrefix(XPathString(args[0](c)), XPathString(args[1](c)))
+		}, arity(2)
+	case "string-length":
+		return func(c xpathContext) any { return float64(len([]rune(stringArg(c)))) }, nil
+	case "normalize-space":
+		return func(c xpathContext) any { return normalize(stringArg(c)) }, nil
+	case "string":
+		return func(c xpathContext) any { return stringArg(c) }, nil
+	case "number":
+		return func(c xpathContext) any { return xpathNumber(stringArg(c)) }, nil
+	case "position":
+		return func(c xpathContext) any { return float64(c.pos) }, arity(0)
+	case "last":
+		return func(c xpathContext) any { return float64(c.size) }, arity(0)
+	case "true", "false":
+		value := name == "true"
+		return func(xpathContext) any { return value }, arity(0)
+	}
+	return nil, fmt.Errorf("unsupported function %s", name)
+}
+
+type xpathStep struct {
+	axis       int
+	match      func(*Node) bool
+	predicates []expr
+}
+
+func (p *xpathParser) parsePath() (expr, error) {
+	var (
+		absolute bool
+		axis     = axisChild
+		steps    []xpathStep
+	)
+	switch {
+	case p.symbol("//"):
+		absolute, axis = true, axisDescendant
+	case p.symbol("/"):
+		absolute = true
+		if !p.stepStart() {
+			return func(c xpathContext) any { return []*Node{c.root} }, nil
+		}
+	}
+
+	for {
+		step, err := p.parseStep(axis)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+
+		if p.symbol("//") {
+			axis = axisDescendant
+		} else if p.symbol("/") {
+			axis = axisChild
+		} else {
+			break
+		}
+	}
+
+	return func(c xpathContext) any {
+		nodes := []*Node{c.node}
+		if absolute {
+			nodes = []*Node{c.root}
+		}
+		for _, step := range steps {
+			nodes = step.apply(c.root, nodes)
+		}
+		return nodes
+	}, nil
+}
+
+func (p *xpathParser) stepStart() bool {
+	t := p.peek()
+	return t.kind == tokenName || t.kind == tokenSymbol && (t.text == "." || t.text == ".." || t.text == "*")
+}
+
+func (p *xpathParser) parseStep(axis int) (xpathStep, error) {
+	step := xpathStep{axis: axis, match: func(*Node) bool { return true }}
+	t := p.next()
+	switch {
+	case t.kind == tokenSymbol && t.text == ".":
+		step.axis = axisSelf
+	case t.kind == tokenSymbol && t.text == "..":
+		step.axis = axisParent
+	case t.kind == tokenSymbol && t.text == "*":
+	case t.kind == tokenName && t.text == "text" && p.symbol("("):
+		if err := p.expect(")"); err != nil {
+			return step, err
+		}
+		step.axis = axisSelf
+	case t.kind == tokenName:
+		match, err := p.nameTest(t.text)
+		if err != nil {
+			return step, err
+		}
+		step.match = match
+	default:
+		return step, fmt.Errorf("unexpected %q", t.text)
+	}
+
+	for p.symbol("[") {
+		predicate, err := p.parseOr()
+		if err != nil {
+			return step, err
+		}
+		if err := p.expect("]"); err != nil {
+			return step, err
+		}
+		step.predicates = append(step.predicates, predicate)
+	}
+	return step, nil
+}
+
+func (p *xpathParser) nameTest(name string) (func(*Node) bool, error) {
+	prefix, local, prefixed := strings.Cut(name, ":")
+	if !prefixed {
+		return func(n *Node) bool { return n.Name.Local == name }, nil
+	}
+	space, found := p.namespaces[prefix]
+	if !found {
+		return nil, fmt.Errorf("unknown namespace prefix %s", prefix)
+	}
+	if local == "*" {
+		return func(n *Node) bool { return n.Name.Space == space }, nil
+	}
+	return func(n *Node) bool { return n.Name.Space == space && n.Name.Local == local }, nil
+}
+
+func (s xpathStep) apply(root *Node, nodes []*Node) []*Node {
+	var result []*Node
+	for _, node := range nodes {
+		var candidates []*Node
+		switch s.axis {
+		case axisChild:
+			candidates = node.Children
+		case axisDescendant:
+			candidates = descendants(node, nil)
+		case axisSelf:
+			candidates = []*Node{node}
+		case axisParent:
+			switch {
+			case node == root:
+			case node.Parent == nil:
+				candidates = []*Node{root}
+			default:
+				candidates = []*Node{node.Parent}
+			}
+		}
+
+		var matched []*Node
+		for _, candidate := range candidates {
+			// virtual root is reached only with self and parent steps
+			if candidate == root || s.match(candidate) {
+				matched = append(matched, candidate)
+			}
+		}
+		for _, predicate := range s.predicates {
+			var kept []*Node
+			for i, candidate := range matched {
+				v := predicate(xpathContext{node: candidate, root: root, pos: i + 1, size: len(matched)})
+				if position, ok := v.(float64); ok {
+					if position == float64(i+1) {
+						kept = append(kept, candidate)
+					}
+				} else if XPathBool(v) {
+					kept = append(kept, candidate)
+				}
+			}
+			matched = kept
+		}
+		result = union(result, matched)
+	}
+	return result
+}
+
+func descendants(node *Node, result []*Node) []*Node {
+	for _, child := range node.Children {
+		result = append(result, child)
+		result = descendants(child, result)
+	}
+	return result
+}
+
+func union(a, b []*Node) []*Node {
+	seen := make(map[*Node]bool, len(a))
+	for _, node := range a {
+		seen[node] = true
+	}
+	for _, node := range b {
+		if !seen[node] {
+			seen[node] = true
+			a = append(a, node)
+		}
+	}
+	return a
+}
+
+// compare implements XPath comparison, node-sets compare true if any node satisfies comparison.
+func compare(op string, a, b any) bool {
+	if nodes, ok := a.([]*Node); ok {
+		for _, node := range nodes {
+			if compare(op, node.Text, b) {
+				return true
+			}
+		}
+		return false
+	}
+	if nodes, ok := b.([]*Node); ok {
+		for _, node := range nodes {
+			if compare(op, a, node.Text) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch op {
+	case "=", "!=":
+		var equal bool
+		_, aBool := a.(bool)
+		_, bBool := b.(bool)
+		_, aNumber := a.(float64)
+		_, bNumber := b.(float64)
+		switch {
+		case aBool || bBool:
+			equal = XPathBool(a) == XPathBool(b)
+		case aNumber || bNumber:
+			equal = xpathNumber(a) == xpathNumber(b)
+		default:
+			equal = XPathString(a) == XPathString(b)
+		}
+		return equal == (op == "=")
+	case "<":
+		return xpathNumber(a) < xpathNumber(b)
+	case "<=":
+		return xpathNumber(a) <= xpathNumber(b)
+	case ">":
+		return xpathNumber(a) > xpathNumber(b)
+	case ">=":
+		return xpathNumber(a) >= xpathNumber(b)
+	}
+	return false
+}